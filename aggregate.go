@@ -0,0 +1,127 @@
+package sparql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/knakk/rdf"
+)
+
+// GroupCount is the number of solutions for one value of the grouping
+// variable, as returned by CountBy.
+type GroupCount struct {
+	Key   rdf.Term // nil for solutions where the grouping variable is unbound
+	Count int
+}
+
+// aggVar is the variable aggregate values are bound to in the helper queries.
+const aggVar = "?_agg"
+
+// Count returns the number of solutions of the graph pattern, e.g.
+// "?s a <http://xmlns.com/foaf/0.1/Person>". Any options given apply to this
+// query only.
+func (r *Repo) Count(pattern string, options ...func(*Repo) error) (int, error) {
+	t, err := r.aggregate("(COUNT(*) AS "+aggVar+")", pattern, options)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(t.String())
+}
+
+// CountBy counts the solutions of the graph pattern for each value of the
+// grouping variable, most frequent first. Any options given apply to this
+// query only.
+func (r *Repo) CountBy(groupVar, pattern string, options ...func(*Repo) error) ([]GroupCount, error) {
+	g := variable(groupVar)
+	q := fmt.Sprintf("SELECT %s (COUNT(*) AS %s) WHERE { %s } GROUP BY %s ORDER BY DESC(%s)",
+		g, aggVar, pattern, g, aggVar)
+	res, err := r.Query(q, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]GroupCount, 0, len(res.Results.Bindings))
+	for _, s := range res.Solutions() {
+		c, ok := s[aggVar[1:]]
+		if !ok {
+			return nil, fmt.Errorf("sparql: no count in response")
+		}
+		n, err := strconv.Atoi(c.String())
+		if err != nil {
+			return nil, fmt.Errorf("sparql: invalid count %q in response", c.String())
+		}
+		counts = append(counts, GroupCount{Key: s[g[1:]], Count: n})
+	}
+	return counts, nil
+}
+
+// Sum returns the sum of the values of the variable in the solutions of the
+// graph pattern. Any options given apply to this query only.
+func (r *Repo) Sum(v, pattern string, options ...func(*Repo) error) (float64, error) {
+	return r.numericAggregate("SUM", v, pattern, options)
+}
+
+// Avg returns the average of the values of the variable in the solutions of
+// the graph pattern. Any options given apply to this query only.
+func (r *Repo) Avg(v, pattern string, options ...func(*Repo) error) (float64, error) {
+	return r.numericAggregate("AVG", v, pattern, options)
+}
+
+// GroupConcat returns the values of the variable in the solutions of the
+// graph pattern, concatenated with the separator in between. Any options
+// given apply to this query only.
+func (r *Repo) GroupConcat(v, pattern, separator string, options ...func(*Repo) error) (string, error) {
+	expr := fmt.Sprintf("(GROUP_CONCAT(%s; SEPARATOR=%s) AS %s)", variable(v), quoteString(separator), aggVar)
+	t, err := r.aggregate(expr, pattern, options)
+	if err != nil {
+		return "", err
+	}
+	return t.String(), nil
+}
+
+func (r *Repo) numericAggregate(fn, v, pattern string, options []func(*Repo) error) (float64, error) {
+	t, err := r.aggregate(fmt.Sprintf("(%s(%s) AS %s)", fn, variable(v), aggVar), pattern, options)
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(t.String(), 64)
+	if err != nil {
+		return 0, fmt.Errorf("sparql: %s of %s is not a number: %q", fn, variable(v), t.String())
+	}
+	return f, nil
+}
+
+// aggregate runs a query projecting the single aggregate expression over the
+// graph pattern, and returns the aggregate value.
+func (r *Repo) aggregate(expr, pattern string, options []func(*Repo) error) (rdf.Term, error) {
+	res, err := r.Query(fmt.Sprintf("SELECT %s WHERE { %s }", expr, pattern), options...)
+	if err != nil {
+		return nil, err
+	}
+	s := res.Solutions()
+	if len(s) == 0 {
+		return nil, fmt.Errorf("sparql: no aggregate in response")
+	}
+	t, ok := s[0][aggVar[1:]]
+	if !ok {
+		// Aggregates are unbound if they fail, e.g. on non-numeric values.
+		return nil, fmt.Errorf("sparql: aggregate %s could not be computed", expr)
+	}
+	return t, nil
+}
+
+// variable returns the variable name in query syntax, adding the leading ?
+// if it is missing.
+func variable(v string) string {
+	if strings.HasPrefix(v, "?") || strings.HasPrefix(v, "$") {
+		return v
+	}
+	return "?" + v
+}
+
+// quoteString returns s as a SPARQL string literal.
+func quoteString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + r.Replace(s) + `"`
+}
@@ -0,0 +1,62 @@
+package sparql
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAggregates(t *testing.T) {
+	var got string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got = req.FormValue("query")
+		switch {
+		case strings.Contains(got, "GROUP BY"):
+			w.Write([]byte(`{"head": {"vars": ["t", "_agg"]}, "results": {"bindings": [
+				{"t": {"type": "uri", "value": "http://example.org/A"}, "_agg": {"type": "typed-literal", "datatype": "http://www.w3.org/2001/XMLSchema#integer", "value": "3"}},
+				{"t": {"type": "uri", "value": "http://example.org/B"}, "_agg": {"type": "typed-literal", "datatype": "http://www.w3.org/2001/XMLSchema#integer", "value": "1"}}
+			]}}`))
+		case strings.Contains(got, "GROUP_CONCAT"):
+			w.Write([]byte(`{"head": {"vars": ["_agg"]}, "results": {"bindings": [{"_agg": {"type": "literal", "value": "a, b"}}]}}`))
+		default:
+			w.Write([]byte(`{"head": {"vars": ["_agg"]}, "results": {"bindings": [{"_agg": {"type": "typed-literal", "datatype": "http://www.w3.org/2001/XMLSchema#decimal", "value": "2.5"}}]}}`))
+		}
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL, "ontotext")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts, err := repo.CountBy("t", "?s a ?t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "SELECT ?t (COUNT(*) AS ?_agg) WHERE { ?s a ?t } GROUP BY ?t ORDER BY DESC(?_agg)"; got != want {
+		t.Errorf("Got query %q; want %q", got, want)
+	}
+	if len(counts) != 2 || counts[0].Key.String() != "http://example.org/A" || counts[0].Count != 3 {
+		t.Errorf("Got counts %v; want A: 3, B: 1", counts)
+	}
+
+	avg, err := repo.Avg("?age", "?s <http://example.org/age> ?age")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if avg != 2.5 {
+		t.Errorf("Got average %v; want 2.5", avg)
+	}
+
+	s, err := repo.GroupConcat("name", "?s <http://example.org/name> ?name", ", ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, `GROUP_CONCAT(?name; SEPARATOR=", ")`) {
+		t.Errorf("Got query %q; want GROUP_CONCAT with separator", got)
+	}
+	if s != "a, b" {
+		t.Errorf("Got %q; want %q", s, "a, b")
+	}
+}
@@ -0,0 +1,219 @@
+package sparql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Pattern is a graph pattern, for use in the WHERE clause of queries built
+// with Select.
+type Pattern interface {
+	writePattern(b *strings.Builder)
+}
+
+// triple is a triple pattern.
+type triple struct {
+	s string
+	p Path
+	o string
+}
+
+func (t triple) writePattern(b *strings.Builder) {
+	fmt.Fprintf(b, "%s %s %s .", t.s, t.p, t.o)
+}
+
+// Triple returns the triple pattern with the given subject, predicate and
+// object. The terms are written as they are in SPARQL syntax: variables,
+// IRIs in angle brackets, prefixed names or literals. The predicate may be
+// any property path.
+func Triple(s string, p Path, o string) Pattern {
+	return triple{s, p, o}
+}
+
+// group is a group graph pattern.
+type group []Pattern
+
+func (g group) writePattern(b *strings.Builder) {
+	writeGroup(b, g)
+}
+
+// Group returns the patterns as a group graph pattern, { patterns }. Use it
+// to combine several patterns into one alternative of a Union, or to limit
+// the scope of filters.
+func Group(patterns ...Pattern) Pattern {
+	return group(patterns)
+}
+
+// optional is an OPTIONAL pattern.
+type optional []Pattern
+
+func (o optional) writePattern(b *strings.Builder) {
+	b.WriteString("OPTIONAL ")
+	writeGroup(b, o)
+}
+
+// Optional returns the pattern OPTIONAL { patterns }, which extends the
+// solutions with the bindings of the patterns where they match, and leaves
+// them unchanged where they don't.
+func Optional(patterns ...Pattern) Pattern {
+	return optional(patterns)
+}
+
+// union is a UNION of group graph patterns.
+type union []Pattern
+
+func (u union) writePattern(b *strings.Builder) {
+	for i, p := range u {
+		if i > 0 {
+			b.WriteString(" UNION ")
+		}
+		if g, ok := p.(group); ok {
+			writeGroup(b, g)
+		} else {
+			writeGroup(b, []Pattern{p})
+		}
+	}
+}
+
+// Union returns the pattern { p1 } UNION { p2 } ..., matching any of the
+// alternatives. Use Group for alternatives of more than one pattern:
+//
+//	Union(
+//		Triple("?p", "foaf:name", "?name"),
+//		Group(Triple("?p", "vcard:fn", "?name"), Filter(Bound(Var("name")))),
+//	)
+func Union(alternatives ...Pattern) Pattern {
+	return union(alternatives)
+}
+
+// SelectQuery builds a SPARQL SELECT query. Create one with Select, and
+// call String for the query text.
+//
+// A SelectQuery is a Pattern too, so it can be used as a subquery in the
+// WHERE clause of another query, with its own projection, grouping and
+// limits. Subqueries can't declare prefixes; declare them on the outermost
+// query instead.
+type SelectQuery struct {
+	prefixes   [][2]string
+	distinct   bool
+	projection []string
+	where      []Pattern
+	groupBy    []string
+	having     []string
+	orderBy    []string
+	limit      int
+	offset     int
+}
+
+// Select starts building a SELECT query projecting the given variables or
+// expressions, e.g. "?s" or "(COUNT(?o) AS ?n)". Without any, all variables
+// are projected.
+func Select(vars ...string) *SelectQuery {
+	return &SelectQuery{projection: vars}
+}
+
+// Prefix declares a prefix for use in the query.
+func (q *SelectQuery) Prefix(name, iri string) *SelectQuery {
+	q.prefixes = append(q.prefixes, [2]string{name, iri})
+	return q
+}
+
+// Distinct eliminates duplicate solutions.
+func (q *SelectQuery) Distinct() *SelectQuery {
+	q.distinct = true
+	return q
+}
+
+// Where adds graph patterns to the WHERE clause.
+func (q *SelectQuery) Where(patterns ...Pattern) *SelectQuery {
+	q.where = append(q.where, patterns...)
+	return q
+}
+
+// GroupBy groups the solutions by the given variables or expressions.
+func (q *SelectQuery) GroupBy(exprs ...string) *SelectQuery {
+	q.groupBy = append(q.groupBy, exprs...)
+	return q
+}
+
+// Having restricts the groups to those for which the conditions hold, e.g.
+// "(COUNT(?o) > 1)".
+func (q *SelectQuery) Having(conds ...string) *SelectQuery {
+	q.having = append(q.having, conds...)
+	return q
+}
+
+// OrderBy orders the solutions by the given conditions, e.g. "?name" or
+// "DESC(?age)".
+func (q *SelectQuery) OrderBy(conds ...string) *SelectQuery {
+	q.orderBy = append(q.orderBy, conds...)
+	return q
+}
+
+// Limit limits the number of solutions.
+func (q *SelectQuery) Limit(n int) *SelectQuery {
+	q.limit = n
+	return q
+}
+
+// Offset skips the first n solutions.
+func (q *SelectQuery) Offset(n int) *SelectQuery {
+	q.offset = n
+	return q
+}
+
+// String returns the query text.
+func (q *SelectQuery) String() string {
+	var b strings.Builder
+	for _, p := range q.prefixes {
+		fmt.Fprintf(&b, "PREFIX %s: <%s>\n", strings.TrimSuffix(p[0], ":"), p[1])
+	}
+	q.writeSelect(&b)
+	return b.String()
+}
+
+// writeSelect writes the query without its prologue.
+func (q *SelectQuery) writeSelect(b *strings.Builder) {
+	b.WriteString("SELECT ")
+	if q.distinct {
+		b.WriteString("DISTINCT ")
+	}
+	if len(q.projection) == 0 {
+		b.WriteString("*")
+	} else {
+		b.WriteString(strings.Join(q.projection, " "))
+	}
+	b.WriteString(" WHERE ")
+	writeGroup(b, q.where)
+	if len(q.groupBy) > 0 {
+		b.WriteString(" GROUP BY " + strings.Join(q.groupBy, " "))
+	}
+	if len(q.having) > 0 {
+		b.WriteString(" HAVING " + strings.Join(q.having, " "))
+	}
+	if len(q.orderBy) > 0 {
+		b.WriteString(" ORDER BY " + strings.Join(q.orderBy, " "))
+	}
+	if q.limit > 0 {
+		fmt.Fprintf(b, " LIMIT %d", q.limit)
+	}
+	if q.offset > 0 {
+		fmt.Fprintf(b, " OFFSET %d", q.offset)
+	}
+}
+
+func (q *SelectQuery) writePattern(b *strings.Builder) {
+	b.WriteString("{ ")
+	q.writeSelect(b)
+	b.WriteString(" }")
+}
+
+// writeGroup writes the patterns as a group graph pattern.
+func writeGroup(b *strings.Builder, patterns []Pattern) {
+	b.WriteString("{")
+	for _, p := range patterns {
+		b.WriteString(" ")
+		p.writePattern(b)
+	}
+	b.WriteString(" }")
+}
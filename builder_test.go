@@ -0,0 +1,69 @@
+package sparql
+
+import "testing"
+
+func TestSelect(t *testing.T) {
+	q := Select("?name").
+		Prefix("foaf", "http://xmlns.com/foaf/0.1/").
+		Distinct().
+		Where(
+			Triple("?p", "a", "foaf:Person"),
+			Triple("?p", Seq(OneOrMore("foaf:knows"), "foaf:name"), "?name"),
+		).
+		OrderBy("?name").
+		Limit(10).
+		Offset(20)
+
+	want := "PREFIX foaf: <http://xmlns.com/foaf/0.1/>\n" +
+		"SELECT DISTINCT ?name WHERE { ?p a foaf:Person . ?p foaf:knows+/foaf:name ?name . } ORDER BY ?name LIMIT 10 OFFSET 20"
+	if got := q.String(); got != want {
+		t.Errorf("Got query:\n%s\nwant:\n%s", got, want)
+	}
+	if err := Validate(q.String()); err != nil {
+		t.Errorf("Built query is invalid: %v", err)
+	}
+}
+
+func TestSelectOptionalUnion(t *testing.T) {
+	q := Select("?p", "?name", "?mbox").Where(
+		Triple("?p", "a", "foaf:Person"),
+		Union(
+			Triple("?p", "foaf:name", "?name"),
+			Group(Triple("?p", "vcard:fn", "?name"), Filter(Ne(Var("name"), Literal("")))),
+		),
+		Optional(
+			Triple("?p", "foaf:mbox", "?mbox"),
+			Optional(Triple("?mbox", "ex:verified", "?v")),
+		),
+	)
+	want := `SELECT ?p ?name ?mbox WHERE { ?p a foaf:Person . { ?p foaf:name ?name . } UNION { ?p vcard:fn ?name . FILTER (?name != "") } ` +
+		`OPTIONAL { ?p foaf:mbox ?mbox . OPTIONAL { ?mbox ex:verified ?v . } } }`
+	if got := q.String(); got != want {
+		t.Errorf("Got query:\n%s\nwant:\n%s", got, want)
+	}
+	if err := Validate("PREFIX foaf: <f:> PREFIX vcard: <v:> PREFIX ex: <e:> " + q.String()); err != nil {
+		t.Errorf("Built query is invalid: %v", err)
+	}
+}
+
+func TestSelectSubquery(t *testing.T) {
+	top := Select("?author", "(COUNT(?book) AS ?n)").
+		Where(Triple("?book", "ex:author", "?author")).
+		GroupBy("?author").
+		Having("(COUNT(?book) > 1)").
+		OrderBy("DESC(?n)").
+		Limit(5)
+	q := Select("?name", "?n").Prefix("ex", "http://example.org/").
+		Where(top, Triple("?author", "ex:name", "?name")).
+		OrderBy("DESC(?n)")
+
+	want := "PREFIX ex: <http://example.org/>\n" +
+		"SELECT ?name ?n WHERE { { SELECT ?author (COUNT(?book) AS ?n) WHERE { ?book ex:author ?author . } GROUP BY ?author HAVING (COUNT(?book) > 1) ORDER BY DESC(?n) LIMIT 5 } " +
+		"?author ex:name ?name . } ORDER BY DESC(?n)"
+	if got := q.String(); got != want {
+		t.Errorf("Got query:\n%s\nwant:\n%s", got, want)
+	}
+	if err := Validate(q.String()); err != nil {
+		t.Errorf("Built query is invalid: %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package sparql
+
+import (
+	"sync"
+	"time"
+)
+
+// CacheAsks makes Ask and Exists cache their results, for applications which
+// ask the same questions over and over, like authorization checks. True
+// results are cached for ttl, and false ones for negativeTTL, or not at all
+// if it is zero. Updates made through the Repo clear the cache, but changes
+// made by other writers are only seen once the results expire.
+func CacheAsks(ttl, negativeTTL time.Duration) func(*Repo) error {
+	return func(r *Repo) error {
+		if err := r.repoScoped("CacheAsks"); err != nil {
+			return err
+		}
+		r.asks = newAskCache(ttl, negativeTTL)
+		return nil
+	}
+}
+
+// askCache caches the results of ASK queries.
+type askCache struct {
+	ttl, negativeTTL time.Duration
+
+	mu      sync.Mutex
+	entries map[string]askEntry
+	sweepAt int // remove expired entries once there are this many
+}
+
+type askEntry struct {
+	v       bool
+	expires time.Time
+}
+
+// minSweep is the number of entries below which askCache does not bother to
+// remove expired ones.
+const minSweep = 64
+
+func newAskCache(ttl, negativeTTL time.Duration) *askCache {
+	return &askCache{ttl: ttl, negativeTTL: negativeTTL, entries: make(map[string]askEntry), sweepAt: minSweep}
+}
+
+func (c *askCache) get(key string) (v, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return false, false
+	}
+	if time.Now().After(e.expires) {
+		delete(c.entries, key)
+		return false, false
+	}
+	return e.v, true
+}
+
+func (c *askCache) put(key string, v bool) {
+	ttl := c.ttl
+	if !v {
+		ttl = c.negativeTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Entries which are never asked for again are removed once the cache has
+	// doubled in size, so that it holds on to no more than the live ones.
+	if len(c.entries) >= c.sweepAt {
+		for k, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if c.sweepAt = 2 * len(c.entries); c.sweepAt < minSweep {
+			c.sweepAt = minSweep
+		}
+	}
+	c.entries[key] = askEntry{v, now.Add(ttl)}
+}
+
+// clear removes all entries. It is safe to call on a nil cache.
+func (c *askCache) clear() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.entries = make(map[string]askEntry)
+	c.mu.Unlock()
+}
@@ -0,0 +1,121 @@
+package sparql
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCacheAsks(t *testing.T) {
+	queries := 0
+	answer := "true"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.FormValue("update") != "" {
+			return
+		}
+		queries++
+		w.Write([]byte(`{"head": {}, "boolean": ` + answer + `}`))
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL, "fuseki", CacheAsks(time.Hour, 20*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+
+	ask := func(pattern string, want bool, wantQueries int) {
+		t.Helper()
+		got, err := repo.Exists(pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want || queries != wantQueries {
+			t.Errorf("Got %v after %d queries; want %v after %d", got, queries, want, wantQueries)
+		}
+	}
+	ask("<a> <p> <b>", true, 1)
+	ask("<a> <p> <b>", true, 1)
+	// Per-query parameters are part of the key.
+	if _, err := repo.Exists("<a> <p> <b>", QueryParam("infer", "false")); err != nil {
+		t.Fatal(err)
+	}
+	ask("<a> <p> <b>", true, 2)
+
+	answer = "false"
+	ask("<a> <p> <c>", false, 3)
+	ask("<a> <p> <c>", false, 3)
+	time.Sleep(30 * time.Millisecond)
+	ask("<a> <p> <c>", false, 4)
+
+	// Updates clear the cache.
+	if _, err := repo.Update("DELETE DATA { <a> <p> <b> }"); err != nil {
+		t.Fatal(err)
+	}
+	ask("<a> <p> <b>", false, 5)
+	answer = "true"
+	if _, err := repo.ConstructFormat("INSERT DATA { <a> <p> <b> }", "text/turtle"); err != nil {
+		t.Fatal(err)
+	}
+	ask("<a> <p> <b>", true, 6)
+
+	if _, err := repo.Exists("<a> <p> <b>", CacheAsks(time.Hour, 0)); err == nil {
+		t.Error("Got no error for CacheAsks given to a single query")
+	}
+}
+
+func TestAskCacheExpiry(t *testing.T) {
+	c := newAskCache(time.Hour, time.Nanosecond)
+	for i := 0; i < 1000; i++ {
+		c.put(fmt.Sprint(i), false)
+	}
+	c.put("live", true)
+	// Expired results are removed as the cache grows, whether asked for again
+	// or not.
+	if n := len(c.entries); n > 2*minSweep {
+		t.Errorf("Got %d entries; want at most %d", n, 2*minSweep)
+	}
+	if _, ok := c.get("999"); ok {
+		t.Error("Got expired result")
+	}
+	if _, ok := c.entries["999"]; ok {
+		t.Error("Expired result was not removed when asked for")
+	}
+	if v, ok := c.get("live"); !ok || !v {
+		t.Errorf("Got %v, %v for live result; want true, true", v, ok)
+	}
+}
+
+func TestCacheAsksTenants(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		answer := strings.Contains(req.FormValue("query"), "tenant-a") && req.Header.Get("X-Tenant") == "a"
+		fmt.Fprintf(w, `{"head": {}, "boolean": %v}`, answer)
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL, "fuseki", CacheAsks(time.Hour, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	tenant := func(name string) *Repo {
+		d, err := repo.With(Header("X-Tenant", name), RewriteQuery(func(q string) (string, error) {
+			return strings.Replace(q, "}", "GRAPH <urn:tenant-"+name+"> {} }", 1), nil
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	// Repos differing in rewrites and headers do not share answers.
+	if ok, err := tenant("a").Exists("<a> <p> <b>"); err != nil || !ok {
+		t.Fatalf("Got %v, %v for tenant a; want true", ok, err)
+	}
+	if ok, err := tenant("b").Exists("<a> <p> <b>"); err != nil || ok {
+		t.Errorf("Got %v, %v for tenant b; want false", ok, err)
+	}
+}
@@ -0,0 +1,196 @@
+package sparql
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// cancelTimeout bounds the time spent asking a store to cancel a query.
+const cancelTimeout = 10 * time.Second
+
+// WithContext sets the context of the requests made by Repo. Typically given
+// to individual queries, it allows them to be cancelled or to time out.
+//
+// For the database types "blazegraph", "neptune" and "stardog", a query which
+// is still running when the context is done is also cancelled on the server,
+// so that abandoned queries do not keep using server resources.
+func WithContext(ctx context.Context) func(*Repo) error {
+	return func(r *Repo) error {
+		r.ctx = ctx
+		return nil
+	}
+}
+
+// assignQueryID tags the query with a fresh id if it may need to be cancelled
+// on the server, returning the query to send and its id.
+func (r *Repo) assignQueryID(q string, form url.Values) (string, string) {
+	d := dialects[r.dbType]
+	if d.cancelQuery == nil || r.ctx.Done() == nil {
+		return q, ""
+	}
+	id := newUUID()
+	if d.assignQueryID != nil {
+		q = d.assignQueryID(q, id, form)
+	}
+	return q, id
+}
+
+// cancelOnDone arranges for the query to be cancelled on the server if the
+// context of Repo is done before the returned function is called.
+func (r *Repo) cancelOnDone(id, q string) (stop func()) {
+	d := dialects[r.dbType]
+	if d.cancelQuery == nil || r.ctx.Done() == nil {
+		return func() {}
+	}
+	stopped := context.AfterFunc(r.ctx, func() {
+		// The context of the query is done, so make a fresh one for cancelling it.
+		ctx, cancel := context.WithTimeout(context.Background(), cancelTimeout)
+		defer cancel()
+		c := r.copy()
+		c.ctx = ctx
+
+		if err := d.cancelQuery(c, id, q); err != nil {
+			r.logf("sparql: failed to cancel query on server: %v", err)
+		}
+	})
+	return func() { stopped() }
+}
+
+func blazegraphAssignQueryID(q, id string, form url.Values) string {
+	form.Set("queryId", id)
+	return q
+}
+
+func blazegraphCancelQuery(r *Repo, id, q string) error {
+	_, err := r.call("POST", r.endpoint, url.Values{"cancelQuery": {""}, "queryId": {id}})
+	return err
+}
+
+const neptuneHints = "http://aws.amazon.com/neptune/vocab/v01/QueryHints#"
+
+// neptuneAssignQueryID sets the queryId query hint, which Neptune requires
+// to be given as a triple pattern in the WHERE clause.
+func neptuneAssignQueryID(q, id string, form url.Values) string {
+	i := whereBrace(q)
+	if i < 0 {
+		return q
+	}
+	hint := fmt.Sprintf(" <%sQuery> <%squeryId> %q .", neptuneHints, neptuneHints, id)
+	return q[:i+1] + hint + q[i+1:]
+}
+
+func neptuneCancelQuery(r *Repo, id, q string) error {
+	_, err := r.call("POST", strings.TrimSuffix(r.endpoint, "/")+"/status",
+		url.Values{"cancelQuery": {""}, "queryId": {id}})
+	return err
+}
+
+// stardogCancelQuery kills the query through the Stardog admin API. Stardog
+// assigns query ids itself, so the running query is looked up by its text.
+func stardogCancelQuery(r *Repo, id, q string) error {
+	u, err := url.Parse(r.endpoint)
+	if err != nil {
+		return err
+	}
+	u.Path, u.RawQuery = "/admin/queries", ""
+
+	b, err := r.call("GET", u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	type running struct {
+		ID    json.Number `json:"id"`
+		Query string      `json:"query"`
+	}
+	var list struct {
+		Queries []running `json:"queries"`
+	}
+	if err = json.Unmarshal(b, &list); err != nil {
+		// Some versions respond with a plain list.
+		if err = json.Unmarshal(b, &list.Queries); err != nil {
+			return err
+		}
+	}
+
+	for _, rq := range list.Queries {
+		if strings.TrimSpace(rq.Query) != strings.TrimSpace(q) {
+			continue
+		}
+		if _, err = r.call("DELETE", u.String()+"/"+url.PathEscape(rq.ID.String()), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// whereBrace returns the index of the opening brace of the WHERE clause of
+// the query, or -1 if not found. Braces in comments, IRIs and literals, and
+// those of CONSTRUCT templates, are skipped.
+func whereBrace(q string) int {
+	var sawConstruct, sawWhere bool
+	for i := 0; i < len(q); i++ {
+		switch c := q[i]; {
+		case c == '#':
+			for i < len(q) && q[i] != '\n' {
+				i++
+			}
+		case c == '<':
+			for i < len(q) && q[i] != '>' {
+				i++
+			}
+		case c == '"' || c == '\'':
+			quote := c
+			for i++; i < len(q) && q[i] != quote; i++ {
+				if q[i] == '\\' {
+					i++
+				}
+			}
+		case c == '{':
+			if sawWhere || !sawConstruct {
+				return i
+			}
+			// Skip the CONSTRUCT template.
+			for depth := 0; i < len(q); i++ {
+				if q[i] == '{' {
+					depth++
+				} else if q[i] == '}' {
+					if depth--; depth == 0 {
+						break
+					}
+				}
+			}
+		case isNameChar(c):
+			j := i
+			for j < len(q) && isNameChar(q[j]) {
+				j++
+			}
+			switch strings.ToUpper(q[i:j]) {
+			case "CONSTRUCT":
+				sawConstruct = true
+			case "WHERE":
+				sawWhere = true
+			}
+			i = j - 1
+		}
+	}
+	return -1
+}
+
+func isNameChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_' || c == ':'
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,89 @@
+package sparql
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCancelOnServer(t *testing.T) {
+	cancelled := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		req.ParseForm()
+		if _, ok := req.Form["cancelQuery"]; ok {
+			cancelled <- req.Form.Get("queryId")
+			return
+		}
+		// Simulate a slow query, which gets abandoned by the client.
+		select {
+		case <-req.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL, "blazegraph")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err = repo.Query("SELECT * WHERE { ?s ?p ?o }", WithContext(ctx)); err == nil {
+		t.Fatal("Query() with expired context succeeded; want error")
+	}
+
+	select {
+	case id := <-cancelled:
+		if id == "" {
+			t.Error("Got cancel request without queryId")
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("Got no cancel request for abandoned query")
+	}
+
+	// Updates have no query ID to cancel them by.
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err = repo.Update("INSERT DATA { <a> <p> <b> }", WithContext(ctx)); err == nil {
+		t.Fatal("Update() with expired context succeeded; want error")
+	}
+	select {
+	case id := <-cancelled:
+		t.Errorf("Got cancel request for update, with queryId %q", id)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestNeptuneAssignQueryID(t *testing.T) {
+	tests := []struct {
+		q, want string
+	}{
+		{
+			"SELECT * WHERE { ?s ?p ?o }",
+			"SELECT * WHERE { HINT ?s ?p ?o }",
+		},
+		{
+			"PREFIX ex: <http://example.org/{x}> CONSTRUCT { ?s ex:p ?o } WHERE { ?s ?p ?o }",
+			"PREFIX ex: <http://example.org/{x}> CONSTRUCT { ?s ex:p ?o } WHERE { HINT ?s ?p ?o }",
+		},
+		{
+			"# {\nASK { ?s ?p \"{\" }",
+			"# {\nASK { HINT ?s ?p \"{\" }",
+		},
+		{
+			"DESCRIBE <http://example.org/x>",
+			"DESCRIBE <http://example.org/x>",
+		},
+	}
+	hint := "<" + neptuneHints + "Query> <" + neptuneHints + "queryId> \"id\" ."
+	for _, test := range tests {
+		want := strings.Replace(test.want, "HINT", hint, 1)
+		if got := neptuneAssignQueryID(test.q, "id", nil); got != want {
+			t.Errorf("neptuneAssignQueryID(%q) = %q; want %q", test.q, got, want)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package sparql
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrConflict is returned by UpdateIf and UpdateIfVersion when their
+// condition doesn't hold, as the data was changed by another writer.
+var ErrConflict = errors.New("sparql: update conflicts with a concurrent change")
+
+// Ask performs the ASK query on the Repo, and returns its result. Results
+// may come from a cache; see CacheAsks. Any options given apply to this
+// query only.
+func (r *Repo) Ask(q string, options ...func(*Repo) error) (bool, error) {
+	if r.closed() {
+		return false, ErrClosed
+	}
+	r, err := r.derive(options...)
+	if err != nil {
+		return false, err
+	}
+	if r.asks == nil || r.dryRun {
+		return r.ask(q)
+	}
+
+	key, err := r.askKey(q)
+	if err != nil {
+		return false, err
+	}
+	if v, ok := r.asks.get(key); ok {
+		return v, nil
+	}
+	v, err := r.ask(q)
+	if err != nil {
+		return false, err
+	}
+	r.asks.put(key, v)
+	return v, nil
+}
+
+// askKey returns the key to cache the result of the ASK query under. It is
+// made of everything which may affect the result: the query as rewritten,
+// as well as the endpoint, parameters, headers and user it is sent with, as
+// the cache is shared by Repos which may differ in these.
+func (r *Repo) askKey(q string) (string, error) {
+	// The query is linted when it is sent, so lint hooks are not run here.
+	q, err := r.rewrite(r.addPrefixes(q))
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString(q + "\x00" + r.endpoint + "\x00" + r.params.Encode() + "\x00")
+	r.headers.Write(&b)
+	if r.basicAuth != nil {
+		b.WriteString("\x00" + r.basicAuth.Username())
+	}
+	b.WriteString("\x00" + r.digestUsername)
+	return b.String(), nil
+}
+
+func (r *Repo) ask(q string) (bool, error) {
+	res, err := r.Query(q)
+	if err != nil {
+		return false, err
+	}
+	return res.Boolean, nil
+}
+
+// Exists reports whether the graph pattern has any solutions, e.g.
+// "<http://example.org/alice> a <http://xmlns.com/foaf/0.1/Person>". Like
+// Ask, it may be answered from a cache. Any options given apply to this
+// query only.
+func (r *Repo) Exists(pattern string, options ...func(*Repo) error) (bool, error) {
+	return r.Ask("ASK { "+pattern+" }", options...)
+}
+
+// GraphVersion returns a version of the named graph: a hash of its triples,
+// which changes whenever the triples do. Blank nodes are hashed without their
+// labels, which stores are free to change.
+func (r *Repo) GraphVersion(graph string, options ...func(*Repo) error) (string, error) {
+	if err := ValidateIRI(graph); err != nil {
+		return "", err
+	}
+	res, err := r.Query(graphVersionQuery(graph), options...)
+	if err != nil {
+		return "", err
+	}
+	return graphVersion(res), nil
+}
+
+func graphVersionQuery(graph string) string {
+	return "SELECT ?s ?p ?o WHERE { GRAPH <" + graph + "> { ?s ?p ?o } }"
+}
+
+func graphVersion(res *Results) string {
+	lines := make([]string, len(res.Results.Bindings))
+	for i, b := range res.Results.Bindings {
+		for _, v := range []string{"s", "p", "o"} {
+			t := b[v]
+			if t.Type == "bnode" {
+				t.Value = ""
+			}
+			// Ignore differences in serialization, as solutionKey does.
+			lines[i] += solutionKey(map[string]binding{v: t}, []string{v})
+		}
+	}
+	sort.Strings(lines)
+	h := sha256.New()
+	for _, l := range lines {
+		h.Write([]byte(l))
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// UpdateIf performs the update only if the guard, an ASK query, is true, and
+// returns ErrConflict otherwise. On the ontotext and stardog database types
+// the guard is checked and the update performed in one transaction; on other
+// stores a concurrent change between the two may go unnoticed.
+func (r *Repo) UpdateIf(guard, update string, options ...func(*Repo) error) (*UpdateResult, error) {
+	return r.updateIf(func(query func(string) (*Results, error)) error {
+		res, err := query(guard)
+		if err != nil {
+			return err
+		}
+		if !res.Boolean {
+			return ErrConflict
+		}
+		return nil
+	}, update, options)
+}
+
+// UpdateIfVersion performs the update only if the named graph still has the
+// version returned by GraphVersion, and returns ErrConflict otherwise. Use it
+// to read, modify and write a graph without losing concurrent changes. It
+// uses transactions as UpdateIf does.
+func (r *Repo) UpdateIfVersion(graph, version, update string, options ...func(*Repo) error) (*UpdateResult, error) {
+	if err := ValidateIRI(graph); err != nil {
+		return nil, err
+	}
+	return r.updateIf(func(query func(string) (*Results, error)) error {
+		res, err := query(graphVersionQuery(graph))
+		if err != nil {
+			return err
+		}
+		if graphVersion(res) != version {
+			return ErrConflict
+		}
+		return nil
+	}, update, options)
+}
+
+// updateIf performs the update if check succeeds, querying the store with
+// the function it is given.
+func (r *Repo) updateIf(check func(query func(string) (*Results, error)) error, update string, options []func(*Repo) error) (*UpdateResult, error) {
+	if r.closed() {
+		return nil, ErrClosed
+	}
+	r, err := r.derive(options...)
+	if err != nil {
+		return nil, err
+	}
+	if r.readOnly {
+		return nil, ErrReadOnly
+	}
+
+	begin := dialects[r.dbType].begin
+	if begin == nil || r.dryRun {
+		if err := check(func(q string) (*Results, error) { return r.Query(q) }); err != nil {
+			return nil, err
+		}
+		return r.Update(update)
+	}
+
+	start := time.Now()
+	res, err := r.updateIfInTransaction(begin, check, update)
+	r.stats.record(update, time.Since(start), err)
+	return res, err
+}
+
+// updateIfInTransaction implements updateIf with a transaction, in which the
+// check and the update are made.
+func (r *Repo) updateIfInTransaction(begin func(*Repo) (*transaction, error), check func(query func(string) (*Results, error)) error, update string) (*UpdateResult, error) {
+	update, err := r.prepare(update)
+	if err != nil {
+		return nil, err
+	}
+	defer r.asks.clear()
+	tx, err := begin(r)
+	if err != nil {
+		return nil, err
+	}
+	res, err := func() (*UpdateResult, error) {
+		// Guards are prepared like queries made through r.Query.
+		query := func(q string) (res *Results, err error) {
+			start := time.Now()
+			defer func(q string) { r.stats.record(q, time.Since(start), err) }(q)
+			if q, err = r.prepare(q); err != nil {
+				return nil, err
+			}
+			return tx.query(q)
+		}
+		if err := check(query); err != nil {
+			return nil, err
+		}
+		body, err := tx.update(update)
+		if err != nil {
+			return nil, err
+		}
+		var res UpdateResult
+		res.add(r, body)
+		return &res, nil
+	}()
+	if err != nil {
+		if rerr := tx.rollback(); rerr != nil {
+			r.logf("sparql: rolling back transaction: %v", rerr)
+		}
+		return nil, err
+	}
+	if err := tx.commit(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// txQuery performs a query in a transaction, sending it as the request body.
+func (r *Repo) txQuery(method, addr, q string) (*Results, error) {
+	req, err := http.NewRequest(method, addr, strings.NewReader(q))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/sparql-query")
+	req.Header.Set("Accept", "application/sparql-results+json")
+
+	resp, err := r.control().do(req, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("sparql: query in transaction failed: %s: %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+	return r.parseResults(resp.Body, resp.Header.Get("Content-Type"), nil)
+}
+
+// rdf4jQuery returns the query function of an RDF4J transaction.
+func rdf4jQuery(r *Repo, tx string) func(string) (*Results, error) {
+	return func(q string) (*Results, error) {
+		return r.txQuery("PUT", tx+"?action=QUERY", q)
+	}
+}
+
+// stardogQuery returns the query function of a Stardog transaction.
+func stardogQuery(r *Repo, db, tx string) func(string) (*Results, error) {
+	return func(q string) (*Results, error) {
+		return r.txQuery("POST", db+"/"+tx+"/query", q)
+	}
+}
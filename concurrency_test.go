@@ -0,0 +1,100 @@
+package sparql
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUpdateIf(t *testing.T) {
+	var answer string
+	var updates int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.FormValue("update") != "" {
+			updates++
+			return
+		}
+		w.Write([]byte(`{"head": {}, "boolean": ` + answer + `}`))
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL, "fuseki")
+	if err != nil {
+		t.Fatal(err)
+	}
+	answer = "false"
+	if _, err := repo.UpdateIf("ASK { <a> <p> 1 }", "DELETE DATA { <a> <p> 1 }"); err != ErrConflict {
+		t.Errorf("Got error %v; want ErrConflict", err)
+	}
+	answer = "true"
+	if _, err := repo.UpdateIf("ASK { <a> <p> 1 }", "DELETE DATA { <a> <p> 1 }"); err != nil {
+		t.Error(err)
+	}
+	if updates != 1 {
+		t.Errorf("Got %d updates; want 1", updates)
+	}
+}
+
+func TestUpdateIfVersion(t *testing.T) {
+	graph := `{"head": {"vars": ["s", "p", "o"]}, "results": {"bindings": [
+		{"s": {"type": "uri", "value": "a"}, "p": {"type": "uri", "value": "p"}, "o": {"type": "bnode", "value": "b0"}},
+		{"s": {"type": "uri", "value": "a"}, "p": {"type": "uri", "value": "p"}, "o": {"type": "literal", "value": "1"}}
+	]}}`
+	var calls []string
+	var txQuery string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.URL.Path == "/repo" && req.FormValue("query") != "":
+			// Blank node labels differ, but not the version.
+			w.Write([]byte(strings.Replace(graph, "b0", "x", 1)))
+			return
+		case strings.HasSuffix(req.URL.Path, "/transactions"):
+			w.Header().Set("Location", "/repo/transactions/tx")
+			w.WriteHeader(http.StatusCreated)
+		case req.URL.Query().Get("action") == "QUERY":
+			b, _ := ioutil.ReadAll(req.Body)
+			txQuery = string(b)
+			if !strings.Contains(string(b), "GRAPH <http://example.org/g>") {
+				t.Errorf("Got query %q in transaction", b)
+			}
+			w.Header().Set("Content-Type", "application/sparql-results+json")
+			w.Write([]byte(graph))
+		}
+		calls = append(calls, req.Method+" "+req.URL.Query().Get("action"))
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL+"/repo", "ontotext")
+	if err != nil {
+		t.Fatal(err)
+	}
+	version, err := repo.GraphVersion("http://example.org/g")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.UpdateIfVersion("http://example.org/g", version, "INSERT DATA { <a> <p> 2 }"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(calls, ","), "POST ,PUT QUERY,PUT UPDATE,PUT COMMIT"; got != want {
+		t.Errorf("Got requests %s; want %s", got, want)
+	}
+
+	calls = nil
+	if _, err := repo.UpdateIfVersion("http://example.org/g", "stale", "INSERT DATA { <a> <p> 2 }"); err != ErrConflict {
+		t.Errorf("Got error %v; want ErrConflict", err)
+	}
+	if got, want := strings.Join(calls, ","), "POST ,PUT QUERY,DELETE "; got != want {
+		t.Errorf("Got requests %s; want %s", got, want)
+	}
+
+	// Guards in transactions are rewritten like other queries.
+	tenant := RewriteQuery(func(q string) (string, error) { return q + " # tenant", nil })
+	if _, err := repo.UpdateIfVersion("http://example.org/g", "stale", "INSERT DATA { <a> <p> 2 }", tenant); err != ErrConflict {
+		t.Errorf("Got error %v; want ErrConflict", err)
+	}
+	if !strings.HasSuffix(txQuery, " # tenant") {
+		t.Errorf("Got query %q in transaction; want it rewritten", txQuery)
+	}
+}
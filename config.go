@@ -0,0 +1,191 @@
+package sparql
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"time"
+)
+
+// Config holds the configuration of a Repo, for services that load their
+// configuration from files rather than code. It can be decoded from JSON or
+// YAML. See NewRepoFromConfig.
+type Config struct {
+	// Endpoint is the URL of the SPARQL query endpoint. Required.
+	Endpoint string `json:"endpoint" yaml:"endpoint"`
+
+	// UpdateEndpoint is the URL to send updates to, if different from Endpoint.
+	UpdateEndpoint string `json:"update_endpoint,omitempty" yaml:"update_endpoint,omitempty"`
+
+	// Dialect is the database type of the store, e.g. "ontotext" or "oracle".
+	Dialect string `json:"dialect,omitempty" yaml:"dialect,omitempty"`
+
+	// DefaultGraph is the default graph to query, if not the endpoint's default.
+	DefaultGraph string `json:"default_graph,omitempty" yaml:"default_graph,omitempty"`
+
+	// ReadOnly makes the Repo refuse to send updates.
+	ReadOnly bool `json:"read_only,omitempty" yaml:"read_only,omitempty"`
+
+	// Timeout is the timeout of each request, e.g. "30s". Zero means no timeout.
+	Timeout Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+
+	// Params are extra parameters to be sent to the endpoint with every query.
+	Params map[string]string `json:"params,omitempty" yaml:"params,omitempty"`
+
+	Auth    AuthConfig  `json:"auth,omitempty" yaml:"auth,omitempty"`
+	TLS     TLSSettings `json:"tls,omitempty" yaml:"tls,omitempty"`
+	Retries RetryConfig `json:"retries,omitempty" yaml:"retries,omitempty"`
+}
+
+// AuthConfig holds the credentials used to authenticate against the endpoint.
+// Credentials may also be embedded in the endpoint URLs.
+type AuthConfig struct {
+	// Method is either "digest" (the default) or "basic".
+	Method   string `json:"method,omitempty" yaml:"method,omitempty"`
+	Username string `json:"username,omitempty" yaml:"username,omitempty"`
+	Password string `json:"password,omitempty" yaml:"password,omitempty"`
+}
+
+// TLSSettings holds the TLS configuration used when connecting to the endpoint.
+type TLSSettings struct {
+	// CAFile is a PEM file with certificate authorities to trust, in addition
+	// to those of the system.
+	CAFile string `json:"ca_file,omitempty" yaml:"ca_file,omitempty"`
+
+	// CertFile and KeyFile are the PEM files of a client certificate.
+	CertFile string `json:"cert_file,omitempty" yaml:"cert_file,omitempty"`
+	KeyFile  string `json:"key_file,omitempty" yaml:"key_file,omitempty"`
+
+	// ServerName overrides the name used to verify the server certificate.
+	ServerName string `json:"server_name,omitempty" yaml:"server_name,omitempty"`
+
+	// InsecureSkipVerify disables verification of the server certificate.
+	// Only use this for testing.
+	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty" yaml:"insecure_skip_verify,omitempty"`
+}
+
+// RetryConfig holds the retry policy for failed queries. See Retries.
+type RetryConfig struct {
+	Max     int      `json:"max,omitempty" yaml:"max,omitempty"`
+	Backoff Duration `json:"backoff,omitempty" yaml:"backoff,omitempty"`
+}
+
+// Duration is a time.Duration which is encoded as a string such as "1m30s"
+// in configuration files.
+type Duration time.Duration
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// NewRepoFromConfig creates a new representation of a RDF repository from
+// the given configuration. Any options given are applied after those derived
+// from the configuration.
+func NewRepoFromConfig(c Config, options ...func(*Repo) error) (*Repo, error) {
+	if c.Endpoint == "" {
+		return nil, fmt.Errorf("sparql: config: endpoint is required")
+	}
+	if c.Dialect != "" && !knownDBType(c.Dialect) {
+		return nil, fmt.Errorf("sparql: config: unknown dialect %q", c.Dialect)
+	}
+	if c.Timeout < 0 {
+		return nil, fmt.Errorf("sparql: config: negative timeout %v", time.Duration(c.Timeout))
+	}
+	if c.Retries.Max < 0 || c.Retries.Backoff < 0 {
+		return nil, fmt.Errorf("sparql: config: negative retries")
+	}
+
+	var opts []func(*Repo) error
+
+	if c.UpdateEndpoint != "" {
+		opts = append(opts, UpdateEndpoint(c.UpdateEndpoint))
+	}
+	if c.DefaultGraph != "" {
+		opts = append(opts, DefaultGraph(c.DefaultGraph))
+	}
+	if c.ReadOnly {
+		opts = append(opts, ReadOnly())
+	}
+	if c.Timeout != 0 {
+		opts = append(opts, Timeout(time.Duration(c.Timeout)))
+	}
+	for k, v := range c.Params {
+		opts = append(opts, QueryParam(k, v))
+	}
+
+	if (c.Auth.Username == "") != (c.Auth.Password == "") {
+		return nil, fmt.Errorf("sparql: config: auth username and password must be set together")
+	}
+	if c.Auth.Username != "" {
+		switch c.Auth.Method {
+		case "", "digest":
+			opts = append(opts, DigestAuth(c.Auth.Username, c.Auth.Password))
+		case "basic":
+			opts = append(opts, BasicAuth(c.Auth.Username, c.Auth.Password))
+		default:
+			return nil, fmt.Errorf("sparql: config: unknown auth method %q", c.Auth.Method)
+		}
+	}
+
+	if c.TLS != (TLSSettings{}) {
+		tc, err := c.TLS.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, TLSConfig(tc))
+	}
+
+	if c.Retries.Max > 0 {
+		opts = append(opts, Retries(c.Retries.Max, time.Duration(c.Retries.Backoff)))
+	}
+
+	return NewRepo(c.Endpoint, c.Dialect, append(opts, options...)...)
+}
+
+// tlsConfig builds a *tls.Config from the settings.
+func (s TLSSettings) tlsConfig() (*tls.Config, error) {
+	c := &tls.Config{
+		ServerName:         s.ServerName,
+		InsecureSkipVerify: s.InsecureSkipVerify,
+	}
+
+	if s.CAFile != "" {
+		pem, err := ioutil.ReadFile(s.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("sparql: config: reading CA file: %v", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("sparql: config: no certificates found in CA file %s", s.CAFile)
+		}
+		c.RootCAs = pool
+	}
+
+	if (s.CertFile == "") != (s.KeyFile == "") {
+		return nil, fmt.Errorf("sparql: config: TLS cert file and key file must be set together")
+	}
+	if s.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(s.CertFile, s.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("sparql: config: loading client certificate: %v", err)
+		}
+		c.Certificates = []tls.Certificate{cert}
+	}
+
+	return c, nil
+}
@@ -0,0 +1,44 @@
+package sparql
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestNewRepoFromConfig(t *testing.T) {
+	const conf = `{
+		"endpoint": "http://localhost:7200/repositories/test",
+		"update_endpoint": "http://localhost:7200/repositories/test/statements",
+		"dialect": "ontotext",
+		"timeout": "1500ms",
+		"params": {"infer": "false"},
+		"retries": {"max": 3, "backoff": "200ms"}
+	}`
+
+	var c Config
+	if err := json.Unmarshal([]byte(conf), &c); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := NewRepoFromConfig(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.dbType != "ontotext" {
+		t.Errorf("Got dialect %q; want ontotext", repo.dbType)
+	}
+	if repo.client.Timeout != 1500*time.Millisecond {
+		t.Errorf("Got timeout %v; want 1.5s", repo.client.Timeout)
+	}
+	if repo.params.Get("infer") != "false" {
+		t.Errorf("Got infer param %q; want false", repo.params.Get("infer"))
+	}
+	if repo.retries != 3 || repo.retryBackoff != 200*time.Millisecond {
+		t.Errorf("Got retries %d/%v; want 3/200ms", repo.retries, repo.retryBackoff)
+	}
+
+	if _, err := NewRepoFromConfig(Config{Endpoint: "http://localhost", Dialect: "mysql"}); err == nil {
+		t.Error("NewRepoFromConfig() with unknown dialect succeeded; want error")
+	}
+}
@@ -0,0 +1,78 @@
+package sparql
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/knakk/rdf"
+)
+
+// Decimal is the lexical form of an xsd:decimal, such as "19.99". Use it, or
+// *big.Rat, to bind monetary amounts and other values which must not be
+// rounded by passing through float64.
+type Decimal string
+
+var decimalSyntax = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)$`)
+
+// formatDecimal returns the rational number as a decimal literal, or an
+// error if it has no finite decimal representation, like 1/3.
+func formatDecimal(r *big.Rat) (string, error) {
+	// The number of decimals needed is the larger exponent of 2 and 5 in
+	// the denominator, which must have no other prime factors.
+	d := new(big.Int).Set(r.Denom())
+	digits := 0
+	for _, p := range []int64{2, 5} {
+		n := 0
+		m := new(big.Int)
+		for {
+			q, rem := new(big.Int).QuoRem(d, big.NewInt(p), m)
+			if rem.Sign() != 0 {
+				break
+			}
+			d = q
+			n++
+		}
+		if n > digits {
+			digits = n
+		}
+	}
+	if d.Cmp(big.NewInt(1)) != 0 {
+		return "", fmt.Errorf("sparql: %s has no exact decimal representation", r.String())
+	}
+	if digits == 0 {
+		return r.FloatString(0) + ".0", nil
+	}
+	return r.FloatString(digits), nil
+}
+
+// ParseDecimal returns the exact value of a numeric literal, such as an
+// xsd:decimal or xsd:integer read from query results, without the rounding
+// of converting it to float64.
+func ParseDecimal(t rdf.Term) (*big.Rat, error) {
+	l, ok := t.(rdf.Literal)
+	if !ok {
+		return nil, fmt.Errorf("sparql: %v is not a literal", t)
+	}
+	s := strings.TrimSpace(l.String())
+	if !decimalSyntax.MatchString(s) {
+		return nil, fmt.Errorf("sparql: %q is not a decimal", s)
+	}
+	r, ok := new(big.Rat).SetString(strings.TrimPrefix(s, "+"))
+	if !ok {
+		return nil, fmt.Errorf("sparql: %q is not a decimal", s)
+	}
+	return r, nil
+}
+
+// SumDecimal returns the sum of the values of the variable in the solutions
+// of the graph pattern, as Sum does, but exactly. Any options given apply to
+// this query only.
+func (r *Repo) SumDecimal(v, pattern string, options ...func(*Repo) error) (*big.Rat, error) {
+	t, err := r.aggregate(fmt.Sprintf("(SUM(%s) AS %s)", variable(v), aggVar), pattern, options)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDecimal(t)
+}
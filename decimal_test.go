@@ -0,0 +1,72 @@
+package sparql
+
+import (
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/knakk/rdf"
+)
+
+func TestFormatDecimal(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		want string
+	}{
+		{big.NewRat(1999, 100), "19.99"},
+		{big.NewRat(-1, 8), "-0.125"},
+		{big.NewRat(12, 1), "12.0"},
+		{big.NewRat(1, 20), "0.05"},
+		{Decimal("0.1"), "0.1"},
+		{Decimal("+12"), "12.0"},
+		{Decimal("-.5"), "-.5"},
+		{Decimal("5."), "5.0"},
+	}
+	for _, test := range tests {
+		got, err := FormatLiteral(test.v)
+		if err != nil {
+			t.Errorf("Got error formatting %v: %v", test.v, err)
+		} else if got != test.want {
+			t.Errorf("Got %s formatting %v; want %s", got, test.v, test.want)
+		}
+	}
+	for _, v := range []interface{}{big.NewRat(1, 3), Decimal("1e5"), Decimal("1.2.3"), Decimal("")} {
+		if got, err := FormatLiteral(v); err == nil {
+			t.Errorf("Got %s and no error formatting %v", got, v)
+		}
+	}
+}
+
+func TestParseDecimal(t *testing.T) {
+	dt, _ := rdf.NewIRI(xsdNS + "decimal")
+	r, err := ParseDecimal(rdf.NewTypedLiteral("1234567890.0000000001", dt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := new(big.Rat).SetString("12345678900000000001/10000000000"); r.Cmp(want) != 0 {
+		t.Errorf("Got %s; want %s", r, want)
+	}
+	if _, err := ParseDecimal(rdf.NewTypedLiteral("abc", dt)); err == nil {
+		t.Error("Got no error parsing non-numeric literal")
+	}
+}
+
+func TestSumDecimal(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"head": {"vars": ["_agg"]}, "results": {"bindings": [{"_agg": {"type": "literal", "datatype": "http://www.w3.org/2001/XMLSchema#decimal", "value": "0.30"}}]}}`))
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL, "fuseki")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum, err := repo.SumDecimal("amount", "?s <amount> ?amount")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.Cmp(big.NewRat(3, 10)) != 0 {
+		t.Errorf("Got sum %s; want 3/10", sum)
+	}
+}
@@ -0,0 +1,115 @@
+package sparql
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DeleteByPattern deletes the triples matching the graph pattern, e.g.
+// "?s a <http://example.org/Temp> . ?s ?p ?o", in batches of batchSize
+// solutions: each batch is selected, then deleted by an update binding the
+// selected values, so that no single update has to delete everything, which
+// often times out on big stores. Solutions with blank nodes, which can't be
+// selected and deleted by value, are deleted by a final DELETE WHERE.
+//
+// It returns the number of solutions deleted. With the ReportProgress
+// option, progress is reported as batches are deleted, with Rows counting
+// the solutions deleted so far. Any options given apply to all queries and
+// updates made.
+func (r *Repo) DeleteByPattern(pattern string, batchSize int, options ...func(*Repo) error) (int, error) {
+	if r.closed() {
+		return 0, ErrClosed
+	}
+	r, err := r.derive(options...)
+	if err != nil {
+		return 0, err
+	}
+	if batchSize <= 0 {
+		return 0, errors.New("sparql: batch size must be positive")
+	}
+	toks, err := lex(pattern)
+	if err != nil {
+		return 0, err
+	}
+	var names []string
+	for v := range vars(toks) {
+		names = append(names, "?"+v)
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		_, err := r.Update("DELETE DATA { " + pattern + " }")
+		return 0, err
+	}
+
+	notBlank := make([]string, len(names))
+	for i, v := range names {
+		notBlank[i] = "!isBlank(" + v + ")"
+	}
+	sel := fmt.Sprintf("SELECT DISTINCT %s WHERE { %s FILTER(%s) } LIMIT %d",
+		strings.Join(names, " "), pattern, strings.Join(notBlank, " && "), batchSize)
+
+	// The batches report progress as a whole, instead of every request.
+	p := r.startProgress()
+	r = r.copy()
+	r.progressFunc = nil
+
+	deleted := 0
+	var first string
+	for {
+		res, err := r.Query(sel)
+		if err != nil {
+			return deleted, err
+		}
+		if len(res.Results.Bindings) == 0 {
+			break
+		}
+		k := solutionKey(res.Results.Bindings[0], res.Head.Vars)
+		if k == first {
+			return deleted, errors.New("sparql: batch delete is not making progress")
+		}
+		first = k
+
+		var rows strings.Builder
+		for _, s := range res.Solutions() {
+			rows.WriteString(" (")
+			for i, v := range names {
+				if i > 0 {
+					rows.WriteString(" ")
+				}
+				t, ok := s[v[1:]]
+				if !ok {
+					rows.WriteString("UNDEF")
+					continue
+				}
+				lit, err := FormatLiteral(t)
+				if err != nil {
+					return deleted, err
+				}
+				rows.WriteString(lit)
+			}
+			rows.WriteString(")")
+		}
+		del := fmt.Sprintf("DELETE { %s } WHERE { VALUES (%s) {%s } %s }",
+			pattern, strings.Join(names, " "), rows.String(), pattern)
+		if _, err := r.Update(del); err != nil {
+			return deleted, err
+		}
+		for range res.Results.Bindings {
+			deleted++
+			p.row()
+		}
+	}
+
+	blank := make([]string, len(names))
+	for i, v := range names {
+		blank[i] = "isBlank(" + v + ")"
+	}
+	del := fmt.Sprintf("DELETE { %s } WHERE { %s FILTER(%s) }", pattern, pattern, strings.Join(blank, " || "))
+	if _, err := r.Update(del); err != nil {
+		return deleted, err
+	}
+	p.done(deleted)
+	return deleted, nil
+}
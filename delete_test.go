@@ -0,0 +1,60 @@
+package sparql
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeleteByPattern(t *testing.T) {
+	remaining := 5
+	var updates []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if u := req.FormValue("update"); u != "" {
+			updates = append(updates, u)
+			remaining -= strings.Count(u, ` ("`)
+			return
+		}
+		if !strings.Contains(req.FormValue("query"), "LIMIT 2") {
+			t.Errorf("Got query %q", req.FormValue("query"))
+		}
+		n := remaining
+		if n > 2 {
+			n = 2
+		}
+		var rows []string
+		for i := 0; i < n; i++ {
+			rows = append(rows, fmt.Sprintf(`{"s": {"type": "uri", "value": "http://example.org/s%d"}, "o": {"type": "literal", "value": "%d"}}`, remaining-i, i))
+		}
+		fmt.Fprintf(w, `{"head": {"vars": ["o", "s"]}, "results": {"bindings": [%s]}}`, strings.Join(rows, ","))
+	}))
+	defer ts.Close()
+
+	var reports []Progress
+	repo, err := NewRepo(ts.URL, "fuseki", ReportProgress(time.Nanosecond, func(p Progress) {
+		reports = append(reports, p)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := repo.DeleteByPattern("?s a <http://example.org/Temp> . ?s <http://example.org/p> ?o", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 5 || len(updates) != 4 {
+		t.Errorf("Got %d deleted in %d updates; want 5 in 4", n, len(updates))
+	}
+	want := `DELETE { ?s a <http://example.org/Temp> . ?s <http://example.org/p> ?o } WHERE { VALUES (?o ?s) { ("0" <http://example.org/s5>) ("1" <http://example.org/s4>) } ?s a <http://example.org/Temp> . ?s <http://example.org/p> ?o }`
+	if updates[0] != want {
+		t.Errorf("Got update:\n%s\nwant:\n%s", updates[0], want)
+	}
+	if !strings.Contains(updates[3], "FILTER(isBlank(?o) || isBlank(?s))") {
+		t.Errorf("Got final update %q; want blank node cleanup", updates[3])
+	}
+	if last := reports[len(reports)-1]; !last.Done || last.Rows != 5 {
+		t.Errorf("Got final progress %+v; want 5 rows, done", last)
+	}
+}
@@ -0,0 +1,62 @@
+package sparql
+
+import (
+	"strings"
+
+	"github.com/knakk/rdf"
+)
+
+// describeChunk is the number of resources described per query by
+// DescribeResources.
+const describeChunk = 50
+
+// DescribeResource returns the triples describing the resource with the
+// given IRI. Which triples these are is up to the store; most return the
+// triples with the resource as subject.
+func (r *Repo) DescribeResource(iri string, options ...func(*Repo) error) ([]rdf.Triple, error) {
+	return r.DescribeResources([]string{iri}, options...)
+}
+
+// DescribeResources returns the triples describing the resources with the
+// given IRIs, as DescribeResource does. The resources are described in
+// batches, with a query for every 50 of them, and the triples merged without
+// duplicates. Blank node labels are only unique within a batch.
+//
+// The IRIs are checked with ValidateIRI before any query is sent.
+func (r *Repo) DescribeResources(iris []string, options ...func(*Repo) error) ([]rdf.Triple, error) {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, iri := range iris {
+		if err := ValidateIRI(iri); err != nil {
+			return nil, err
+		}
+		if !seen[iri] {
+			seen[iri] = true
+			unique = append(unique, iri)
+		}
+	}
+
+	var triples []rdf.Triple
+	have := make(map[string]bool)
+	for len(unique) > 0 {
+		n := describeChunk
+		if n > len(unique) {
+			n = len(unique)
+		}
+		q := "DESCRIBE <" + strings.Join(unique[:n], "> <") + ">"
+		unique = unique[n:]
+
+		ts, err := r.Construct(q, options...)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range ts {
+			k := t.Serialize(rdf.NTriples)
+			if !have[k] {
+				have[k] = true
+				triples = append(triples, t)
+			}
+		}
+	}
+	return triples, nil
+}
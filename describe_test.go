@@ -0,0 +1,60 @@
+package sparql
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDescribeResources(t *testing.T) {
+	var queries []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		q := req.FormValue("query")
+		queries = append(queries, q)
+		w.Header().Set("Content-Type", "text/turtle")
+		for _, f := range strings.Fields(q)[1:] {
+			fmt.Fprintf(w, "%s <http://example.org/p> \"x\" .\n", f)
+		}
+		// A triple shared by all descriptions.
+		fmt.Fprintln(w, `<http://example.org/shared> <http://example.org/p> "y" .`)
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL, "fuseki")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var iris []string
+	for i := 0; i < 120; i++ {
+		iris = append(iris, fmt.Sprintf("http://example.org/r%d", i))
+	}
+	iris = append(iris, iris[0])
+	triples, err := repo.DescribeResources(iris)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queries) != 3 {
+		t.Errorf("Got %d queries; want 3", len(queries))
+	}
+	if !strings.HasPrefix(queries[0], "DESCRIBE <http://example.org/r0> <http://example.org/r1> ") {
+		t.Errorf("Got query %q", queries[0])
+	}
+	if len(triples) != 121 {
+		t.Errorf("Got %d triples; want 121", len(triples))
+	}
+}
+
+func TestDescribeResourceInvalidIRI(t *testing.T) {
+	repo, err := NewRepo("http://localhost:1/sparql", "fuseki")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.DescribeResource("http://example.org/a> ?p ?o"); err == nil {
+		t.Error("Got no error for invalid IRI")
+	} else if _, ok := err.(*IRIError); !ok {
+		t.Errorf("Got error %v; want IRIError", err)
+	}
+}
@@ -0,0 +1,123 @@
+package sparql
+
+import (
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dialect holds the store-specific behaviour of a database type. Hooks are
+// nil where the store has no such facility.
+type dialect struct {
+	// assignQueryID tags the query with the given id, so that it can be
+	// cancelled later, returning the query to send.
+	assignQueryID func(q, id string, form url.Values) string
+
+	// cancelQuery asks the store to stop executing the given query.
+	cancelQuery func(r *Repo, id, q string) error
+
+	// validate checks the query (or update) for errors without executing it.
+	validate func(r *Repo, q string, update bool) error
+
+	// explain returns the query plan of the query.
+	explain func(r *Repo, q string) (string, error)
+
+	// sample returns a query for a random sample of n solutions of the pattern.
+	sample func(pattern string, n int) string
+
+	// begin starts a transaction.
+	begin func(r *Repo) (*transaction, error)
+
+	// graphStore returns the address to upload data to the graph, or to the
+	// default graph if graph is empty. It defaults to the SPARQL 1.1 Graph
+	// Store HTTP Protocol at the update endpoint.
+	graphStore func(r *Repo, graph string) string
+
+	// updateResult returns the numbers of triples inserted and deleted, as
+	// reported in the response to an update, if they are.
+	updateResult func(body []byte) (inserted, deleted int, ok bool)
+
+	// serverTimeout sets the parameter or header making the store give up on
+	// queries after d.
+	serverTimeout func(r *Repo, d time.Duration)
+}
+
+// dialects holds the database types Repo knows how to talk to. It is set up
+// in init, as the hooks refer back to it through Repo.
+var dialects map[string]dialect
+
+func init() {
+	dialects = map[string]dialect{
+		"ontotext": {
+			validate: validateByExplain(ontotextExplainQuery),
+			explain:  ontotextExplainQuery,
+			begin:    rdf4jBegin,
+			serverTimeout: func(r *Repo, d time.Duration) {
+				r.params.Set("timeout", strconv.Itoa(int(math.Ceil(d.Seconds()))))
+			},
+			graphStore: func(r *Repo, graph string) string {
+				return graphStoreURL(r.endpoint+"/rdf-graphs/service", "graph", graph)
+			},
+		},
+		"oracle": {},
+		"blazegraph": {
+			assignQueryID: blazegraphAssignQueryID,
+			graphStore: func(r *Repo, graph string) string {
+				return graphStoreURL(r.endpoint, "context-uri", graph)
+			},
+			cancelQuery: blazegraphCancelQuery,
+			// Not used for validation, as Blazegraph executes queries to explain them.
+			explain: blazegraphExplain,
+			sample:  blazegraphSample,
+			serverTimeout: func(r *Repo, d time.Duration) {
+				if r.headers == nil {
+					r.headers = http.Header{}
+				}
+				r.headers.Set("X-BIGDATA-MAX-QUERY-MILLIS", millis(d))
+			},
+		},
+		"neptune": {
+			assignQueryID: neptuneAssignQueryID,
+			cancelQuery:   neptuneCancelQuery,
+			updateResult:  jsonUpdateResult,
+		},
+		"virtuoso": {
+			updateResult: virtuosoUpdateResult,
+			serverTimeout: func(r *Repo, d time.Duration) {
+				r.params.Set("timeout", millis(d))
+			},
+		},
+		"fuseki": {
+			validate: fusekiValidate,
+			serverTimeout: func(r *Repo, d time.Duration) {
+				r.params.Set("timeout", strconv.FormatFloat(d.Seconds(), 'f', -1, 64))
+			},
+			graphStore: func(r *Repo, graph string) string {
+				return graphStoreURL(r.endpoint[:strings.LastIndexByte(r.endpoint, '/')]+"/data", "graph", graph)
+			},
+		},
+		"stardog": {
+			// Stardog assigns query ids itself; queries are found by their text.
+			cancelQuery:  stardogCancelQuery,
+			validate:     validateByExplain(stardogExplain),
+			explain:      stardogExplain,
+			begin:        stardogBegin,
+			updateResult: jsonUpdateResult,
+			graphStore: func(r *Repo, graph string) string {
+				return graphStoreURL(strings.TrimSuffix(r.endpoint, "/query"), "graph", graph)
+			},
+			serverTimeout: func(r *Repo, d time.Duration) {
+				r.params.Set("timeout", millis(d))
+			},
+		},
+	}
+}
+
+// knownDBType reports whether Repo knows how to talk to the given database type.
+func knownDBType(dbType string) bool {
+	_, ok := dialects[dbType]
+	return ok
+}
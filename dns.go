@@ -0,0 +1,122 @@
+package sparql
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+// Resolver sets the resolver used to look up the addresses of the endpoints,
+// instead of the default resolver of the system, e.g. to query a specific DNS
+// server.
+func Resolver(res *net.Resolver) func(*Repo) error {
+	return func(r *Repo) error {
+		if err := r.repoScoped("Resolver"); err != nil {
+			return err
+		}
+		r.setDialer(func(d *dialer) { d.resolver = res })
+		return nil
+	}
+}
+
+// DNSCache caches the addresses the endpoints resolve to for ttl, rather than
+// looking them up for every new connection. When a lookup fails after the
+// addresses have expired, the expired addresses are used instead, so that
+// brief outages of the resolver do not make queries fail.
+func DNSCache(ttl time.Duration) func(*Repo) error {
+	return func(r *Repo) error {
+		if err := r.repoScoped("DNSCache"); err != nil {
+			return err
+		}
+		c := &dnsCache{ttl: ttl, entries: make(map[string]dnsEntry)}
+		r.setDialer(func(d *dialer) { d.cache = c })
+		return nil
+	}
+}
+
+// dialer opens the connections of the HTTP transport, resolving addresses
+// as configured.
+type dialer struct {
+	resolver *net.Resolver
+	cache    *dnsCache
+	addr     string // connect here rather than to the endpoint, if set
+}
+
+// setDialer modifies the dialer of Repo, and makes the transport of Repo
+// use it.
+func (r *Repo) setDialer(modify func(*dialer)) {
+	var d dialer
+	if r.dialer != nil {
+		d = *r.dialer
+	}
+	modify(&d)
+	r.dialer = &d
+	r.ownTransport().DialContext = d.dialContext
+}
+
+func (d *dialer) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	// The same settings as the dialer of http.DefaultTransport.
+	nd := net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Resolver: d.resolver}
+
+	addr = d.dialAddress(ctx, addr)
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || d.cache == nil || net.ParseIP(host) != nil {
+		return nd.DialContext(ctx, network, addr)
+	}
+	addrs, err := d.cache.lookup(ctx, host, d.lookupHost)
+	if err != nil {
+		return nil, err
+	}
+	var conn net.Conn
+	for _, a := range addrs {
+		if conn, err = nd.DialContext(ctx, network, net.JoinHostPort(a, port)); err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
+
+func (d *dialer) lookupHost(ctx context.Context, host string) ([]string, error) {
+	res := d.resolver
+	if res == nil {
+		res = net.DefaultResolver
+	}
+	return res.LookupHost(ctx, host)
+}
+
+// dnsCache holds the addresses hosts resolved to.
+type dnsCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]dnsEntry
+}
+
+type dnsEntry struct {
+	addrs   []string
+	expires time.Time
+}
+
+// lookup returns the addresses of host, resolving them with resolve unless
+// they are cached.
+func (c *dnsCache) lookup(ctx context.Context, host string, resolve func(context.Context, string) ([]string, error)) ([]string, error) {
+	c.mu.Lock()
+	e, ok := c.entries[host]
+	c.mu.Unlock()
+	if ok && time.Now().Before(e.expires) {
+		return e.addrs, nil
+	}
+
+	addrs, err := resolve(ctx, host)
+	if err != nil {
+		if ok {
+			return e.addrs, nil
+		}
+		return nil, err
+	}
+	c.mu.Lock()
+	c.entries[host] = dnsEntry{addrs: addrs, expires: time.Now().Add(c.ttl)}
+	c.mu.Unlock()
+	return addrs, nil
+}
@@ -0,0 +1,89 @@
+package sparql
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDNSCache(t *testing.T) {
+	var lookups int
+	fail := false
+	resolve := func(ctx context.Context, host string) ([]string, error) {
+		lookups++
+		if fail {
+			return nil, errors.New("no such host")
+		}
+		return []string{"192.0.2.1"}, nil
+	}
+
+	c := &dnsCache{ttl: time.Hour, entries: make(map[string]dnsEntry)}
+	for i := 0; i < 3; i++ {
+		addrs, err := c.lookup(context.Background(), "sparql.example.org", resolve)
+		if err != nil || len(addrs) != 1 || addrs[0] != "192.0.2.1" {
+			t.Fatalf("Got %v, %v; want [192.0.2.1]", addrs, err)
+		}
+	}
+	if lookups != 1 {
+		t.Errorf("Got %d lookups; want 1", lookups)
+	}
+
+	// Expired addresses are used if the lookup fails.
+	c.ttl = 0
+	c.entries["sparql.example.org"] = dnsEntry{addrs: []string{"192.0.2.1"}}
+	fail = true
+	if addrs, err := c.lookup(context.Background(), "sparql.example.org", resolve); err != nil || len(addrs) != 1 {
+		t.Errorf("Got %v, %v; want the expired addresses", addrs, err)
+	}
+	if _, err := c.lookup(context.Background(), "other.example.org", resolve); err == nil {
+		t.Error("Got no error for failed lookup of uncached host")
+	}
+}
+
+func TestDNSCacheQuery(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(testEmptyResults))
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(strings.Replace(ts.URL, "127.0.0.1", "localhost", 1), "fuseki", DNSCache(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = repo.Query("SELECT * WHERE { ?s ?p ?o }"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := repo.dialer.cache.entries["localhost"]; !ok {
+		t.Error("Got no cached addresses for localhost")
+	}
+	if _, err = repo.Query("SELECT * WHERE { ?s ?p ?o }", DNSCache(time.Minute)); err == nil {
+		t.Error("Got no error for DNSCache given to a single query")
+	}
+}
+
+func TestResolver(t *testing.T) {
+	var dials int32
+	res := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			atomic.AddInt32(&dials, 1)
+			return nil, errors.New("resolver unavailable")
+		},
+	}
+	repo, err := NewRepo("http://sparql.invalid/query", "fuseki", Resolver(res))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = repo.Query("ASK {}"); err == nil {
+		t.Error("Got no error; want the lookup to fail")
+	}
+	if atomic.LoadInt32(&dials) == 0 {
+		t.Error("Custom resolver was not used")
+	}
+}
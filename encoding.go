@@ -0,0 +1,98 @@
+package sparql
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ContentDecoder registers a decoder for responses with the content coding,
+// e.g. "br" or "zstd", and advertises the coding to the endpoint in the
+// Accept-Encoding header of requests. Responses are decoded transparently.
+// Decoders are given by the application, so that the package does not depend
+// on any compression library, as in:
+//
+//	ContentDecoder("br", func(r io.Reader) (io.ReadCloser, error) {
+//		return io.NopCloser(brotli.NewReader(r)), nil
+//	})
+//
+// Codings are preferred in the order they are registered. Once a decoder is
+// registered, gzip is also accepted, after the registered codings, unless a
+// decoder has been registered for it as well.
+func ContentDecoder(coding string, decode func(io.Reader) (io.ReadCloser, error)) func(*Repo) error {
+	return func(r *Repo) error {
+		coding = strings.ToLower(coding)
+		decoders := make(map[string]func(io.Reader) (io.ReadCloser, error), len(r.decoders)+1)
+		for k, v := range r.decoders {
+			decoders[k] = v
+		}
+		if _, ok := decoders[coding]; !ok {
+			r.codings = append(r.codings[:len(r.codings):len(r.codings)], coding)
+		}
+		decoders[coding] = decode
+		r.decoders = decoders
+		return nil
+	}
+}
+
+// acceptEncoding returns the Accept-Encoding header for the registered
+// content decoders, or the empty string if there are none.
+func (r *Repo) acceptEncoding() string {
+	if len(r.decoders) == 0 {
+		return ""
+	}
+	codings := r.codings
+	if _, ok := r.decoders["gzip"]; !ok {
+		codings = append(codings[:len(codings):len(codings)], "gzip")
+	}
+	return strings.Join(codings, ", ")
+}
+
+// decodeBody replaces the body of the response with its decoded content, if
+// it has a content coding and content decoders are registered.
+func (r *Repo) decodeBody(resp *http.Response) error {
+	if len(r.decoders) == 0 || resp.ContentLength == 0 {
+		return nil
+	}
+	coding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	if coding == "" || coding == "identity" {
+		return nil
+	}
+	decode, ok := r.decoders[coding]
+	if !ok && coding == "gzip" {
+		decode, ok = gunzip, true
+	}
+	if !ok {
+		return fmt.Errorf("sparql: unsupported content coding %q in response", coding)
+	}
+	body, err := decode(resp.Body)
+	if err != nil {
+		return fmt.Errorf("sparql: decoding %s response: %v", coding, err)
+	}
+	resp.Body = &decodedBody{ReadCloser: body, raw: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+func gunzip(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// decodedBody closes both the decoder and the raw body it reads from.
+type decodedBody struct {
+	io.ReadCloser
+	raw io.ReadCloser
+}
+
+func (b *decodedBody) Close() error {
+	err := b.ReadCloser.Close()
+	if err2 := b.raw.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
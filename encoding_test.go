@@ -0,0 +1,83 @@
+package sparql
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// reverse is a toy content coding for testing, which reverses the body.
+func reverse(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i, c := range b {
+		r[len(b)-1-i] = c
+	}
+	return r
+}
+
+func TestContentDecoder(t *testing.T) {
+	var accept string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		accept = req.Header.Get("Accept-Encoding")
+		switch req.FormValue("coding") {
+		case "rev":
+			w.Header().Set("Content-Encoding", "rev")
+			w.Write(reverse([]byte(testEmptyResults)))
+		case "gzip":
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			gz.Write([]byte(testEmptyResults))
+			gz.Close()
+		case "unknown":
+			w.Header().Set("Content-Encoding", "unknown")
+			w.Write([]byte(testEmptyResults))
+		default:
+			w.Write([]byte(testEmptyResults))
+		}
+	}))
+	defer ts.Close()
+
+	var closed bool
+	repo, err := NewRepo(ts.URL, "fuseki", ContentDecoder("rev", func(r io.Reader) (io.ReadCloser, error) {
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return &closeRecorder{Reader: bytes.NewReader(reverse(b)), closed: &closed}, nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, coding := range []string{"rev", "gzip", ""} {
+		if _, err = repo.Query("SELECT * WHERE { ?s ?p ?o }", QueryParam("coding", coding)); err != nil {
+			t.Fatalf("%q coding: %v", coding, err)
+		}
+	}
+	if accept != "rev, gzip" {
+		t.Errorf("Got Accept-Encoding %q; want %q", accept, "rev, gzip")
+	}
+	if !closed {
+		t.Error("Decoder was not closed")
+	}
+
+	_, err = repo.Query("SELECT * WHERE { ?s ?p ?o }", QueryParam("coding", "unknown"))
+	if err == nil || !strings.Contains(err.Error(), "unsupported content coding") {
+		t.Errorf("Got error %v; want unsupported content coding", err)
+	}
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed *bool
+}
+
+func (c *closeRecorder) Close() error {
+	*c.closed = true
+	return nil
+}
@@ -0,0 +1,76 @@
+package sparql
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// NewRepoFromEnv creates a new representation of a RDF repository configured
+// from the following environment variables:
+//
+//   - SPARQL_ENDPOINT: the query endpoint (required)
+//   - SPARQL_DB_TYPE: the database type, e.g. "ontotext" or "oracle"
+//   - SPARQL_UPDATE_ENDPOINT: a separate endpoint for updates
+//   - SPARQL_USERNAME, SPARQL_PASSWORD: credentials for authentication
+//   - SPARQL_AUTH_METHOD: "digest" (the default) or "basic"
+//   - SPARQL_TIMEOUT: the request timeout, e.g. "1500ms" or "30s"
+//   - SPARQL_DEFAULT_GRAPH: the default graph to query
+//   - SPARQL_READ_ONLY: whether updates should be refused, e.g. "true"
+//   - SPARQL_RETRIES: the number of times to retry failed queries
+//   - SPARQL_CA_FILE: a PEM file with certificate authorities to trust
+//
+// Any options given are applied after those derived from the environment.
+func NewRepoFromEnv(options ...func(*Repo) error) (*Repo, error) {
+	c := Config{
+		Endpoint:       os.Getenv("SPARQL_ENDPOINT"),
+		UpdateEndpoint: os.Getenv("SPARQL_UPDATE_ENDPOINT"),
+		Dialect:        os.Getenv("SPARQL_DB_TYPE"),
+		DefaultGraph:   os.Getenv("SPARQL_DEFAULT_GRAPH"),
+		Auth: AuthConfig{
+			Method:   os.Getenv("SPARQL_AUTH_METHOD"),
+			Username: os.Getenv("SPARQL_USERNAME"),
+			Password: os.Getenv("SPARQL_PASSWORD"),
+		},
+		TLS: TLSSettings{
+			CAFile: os.Getenv("SPARQL_CA_FILE"),
+		},
+	}
+
+	if c.Endpoint == "" {
+		return nil, fmt.Errorf("sparql: SPARQL_ENDPOINT is not set")
+	}
+	if c.Dialect != "" && !knownDBType(c.Dialect) {
+		return nil, fmt.Errorf("sparql: invalid SPARQL_DB_TYPE %q", c.Dialect)
+	}
+	if (c.Auth.Username == "") != (c.Auth.Password == "") {
+		return nil, fmt.Errorf("sparql: SPARQL_USERNAME and SPARQL_PASSWORD must be set together")
+	}
+
+	if v := os.Getenv("SPARQL_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("sparql: invalid SPARQL_TIMEOUT %q: want a duration such as \"30s\"", v)
+		}
+		c.Timeout = Duration(d)
+	}
+
+	if v := os.Getenv("SPARQL_READ_ONLY"); v != "" {
+		ro, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("sparql: invalid SPARQL_READ_ONLY %q: want true or false", v)
+		}
+		c.ReadOnly = ro
+	}
+
+	if v := os.Getenv("SPARQL_RETRIES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("sparql: invalid SPARQL_RETRIES %q: want a non-negative integer", v)
+		}
+		c.Retries = RetryConfig{Max: n, Backoff: Duration(100 * time.Millisecond)}
+	}
+
+	return NewRepoFromConfig(c, options...)
+}
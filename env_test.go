@@ -0,0 +1,49 @@
+package sparql
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewRepoFromEnv(t *testing.T) {
+	t.Setenv("SPARQL_ENDPOINT", "http://localhost:7200/repositories/test")
+	t.Setenv("SPARQL_UPDATE_ENDPOINT", "http://localhost:7200/repositories/test/statements")
+	t.Setenv("SPARQL_DB_TYPE", "ontotext")
+	t.Setenv("SPARQL_TIMEOUT", "2s")
+	t.Setenv("SPARQL_READ_ONLY", "true")
+
+	repo, err := NewRepoFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.updateURL() != "http://localhost:7200/repositories/test/statements" {
+		t.Errorf("Got update endpoint %q", repo.updateURL())
+	}
+	if repo.client.Timeout != 2*time.Second {
+		t.Errorf("Got timeout %v; want 2s", repo.client.Timeout)
+	}
+	if !repo.readOnly {
+		t.Error("Repo not read-only; want read-only")
+	}
+}
+
+func TestNewRepoFromEnvErrors(t *testing.T) {
+	tests := []struct {
+		key, value string
+	}{
+		{"SPARQL_ENDPOINT", ""},
+		{"SPARQL_DB_TYPE", "mysql"},
+		{"SPARQL_TIMEOUT", "10"},
+		{"SPARQL_USERNAME", "dba"},
+		{"SPARQL_READ_ONLY", "maybe"},
+	}
+
+	for _, test := range tests {
+		t.Setenv("SPARQL_ENDPOINT", "http://localhost:8890/sparql")
+		t.Setenv(test.key, test.value)
+		if _, err := NewRepoFromEnv(); err == nil {
+			t.Errorf("NewRepoFromEnv() with %s=%q succeeded; want error", test.key, test.value)
+		}
+		t.Setenv(test.key, "")
+	}
+}
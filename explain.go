@@ -0,0 +1,127 @@
+package sparql
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// ErrNotSupported is returned when the database type of Repo does not
+// support the requested operation.
+var ErrNotSupported = errors.New("sparql: operation not supported by database type")
+
+// Explain returns the query plan of the query, as produced by the explain
+// facility of the store, without executing the query. The format of the
+// plan is specific to the store. It is supported for the database types
+// "ontotext" (GraphDB), "stardog" and "blazegraph"; note that Blazegraph
+// executes the query to explain it, and returns the plan as HTML.
+//
+// Any options given apply to this query only.
+func (r *Repo) Explain(q string, options ...func(*Repo) error) (string, error) {
+	if r.closed() {
+		return "", ErrClosed
+	}
+	r, err := r.derive(options...)
+	if err != nil {
+		return "", err
+	}
+
+	explain := dialects[r.dbType].explain
+	if explain == nil {
+		return "", ErrNotSupported
+	}
+	if q, err = r.prepare(q); err != nil {
+		return "", err
+	}
+	if isUpdate(q) {
+		return "", errors.New("sparql: cannot explain updates")
+	}
+	return explain(r, q)
+}
+
+// validateByExplain returns a validation hook which has the store explain
+// queries, which requires the store to parse them.
+func validateByExplain(explain func(r *Repo, q string) (string, error)) func(r *Repo, q string, update bool) error {
+	return func(r *Repo, q string, update bool) error {
+		if update {
+			return nil
+		}
+		_, err := explain(r, q)
+		return err
+	}
+}
+
+const ontotextExplainGraph = "http://www.ontotext.com/explain"
+
+// ontotextExplainQuery asks GraphDB for the plan by querying the explain pseudo-graph.
+func ontotextExplainQuery(r *Repo, q string) (string, error) {
+	i := datasetClausePos(q)
+	if i < 0 {
+		return "", errors.New("sparql: cannot explain query without WHERE clause")
+	}
+	// Explaining is how dry runs are validated, so it must not be one itself.
+	c := r.copy()
+	c.dryRun = false
+	// The query has been prepared already, so it is sent as is.
+	res, err := c.sendQuery(q[:i] + "FROM <" + ontotextExplainGraph + "> " + q[i:])
+	if err != nil {
+		return "", err
+	}
+	for _, s := range res.Solutions() {
+		for _, v := range res.Head.Vars {
+			if t, ok := s[v]; ok {
+				return t.String(), nil
+			}
+		}
+	}
+	return "", errors.New("sparql: no query plan in response")
+}
+
+// stardogExplain uses the explain endpoint of the Stardog database, which is
+// next to its query endpoint.
+func stardogExplain(r *Repo, q string) (string, error) {
+	u, err := url.Parse(r.endpoint)
+	if err != nil {
+		return "", err
+	}
+	u.Path = strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), "/query") + "/explain"
+	u.RawQuery = ""
+
+	b, err := r.call("POST", u.String(), url.Values{"query": {q}})
+	return string(b), err
+}
+
+func blazegraphExplain(r *Repo, q string) (string, error) {
+	b, err := r.call("GET", r.endpoint, url.Values{"query": {q}, "explain": {"details"}})
+	return string(b), err
+}
+
+// datasetClausePos returns the position in the query where FROM clauses can
+// be inserted: before the WHERE keyword, or the opening brace of the WHERE
+// clause if the keyword is left out. It returns -1 if there is no WHERE clause.
+func datasetClausePos(q string) int {
+	toks, err := lex(q)
+	if err != nil {
+		return -1
+	}
+	i := skipPrologue(toks)
+	if i < len(toks) && toks[i].is("CONSTRUCT") && i+1 < len(toks) && toks[i+1].is("{") {
+		// Skip the template.
+		depth := 0
+		for i++; i < len(toks); i++ {
+			if toks[i].is("{") {
+				depth++
+			} else if toks[i].is("}") {
+				if depth--; depth == 0 {
+					break
+				}
+			}
+		}
+	}
+	for ; i < len(toks); i++ {
+		if toks[i].is("WHERE") || toks[i].is("{") {
+			return toks[i].pos
+		}
+	}
+	return -1
+}
@@ -0,0 +1,82 @@
+package sparql
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	var got string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got = req.FormValue("query")
+		w.Write([]byte(`{"head": {"vars": ["plan"]}, "results": {"bindings": [{"plan": {"type": "literal", "value": "PLAN"}}]}}`))
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL, "ontotext")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := repo.Explain("PREFIX ex: <http://example.org/> SELECT * WHERE { ?s ex:p ?o }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan != "PLAN" {
+		t.Errorf("Got plan %q; want PLAN", plan)
+	}
+	if want := "SELECT * FROM <" + ontotextExplainGraph + "> WHERE"; !strings.Contains(got, want) {
+		t.Errorf("Got explain query %q; want it to contain %q", got, want)
+	}
+
+	got = ""
+	if _, err = repo.Query("SELECT * WHERE { ?s ?p ?o }", DryRun()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, ontotextExplainGraph) {
+		t.Errorf("Got query %q in dry-run mode; want it explained", got)
+	}
+
+	// Rewrites and prefixes are applied once, in both Explain and dry runs.
+	limit := RewriteQuery(func(q string) (string, error) { return q + " LIMIT 10", nil })
+	prepared, err := repo.With(limit, WithPrefix("ex", "http://example.org/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = prepared.Explain("SELECT * WHERE { ?s ex:p ?o }"); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(got, "LIMIT 10") != 1 || !strings.Contains(got, "PREFIX ex: <http://example.org/>") {
+		t.Errorf("Got explain query %q; want one LIMIT and the prefix declared", got)
+	}
+	if _, err = prepared.Query("SELECT * WHERE { ?s ex:p ?o }", DryRun()); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(got, "LIMIT 10") != 1 {
+		t.Errorf("Got query %q in dry-run mode; want one LIMIT", got)
+	}
+
+	oracle, _ := NewRepo(ts.URL, "oracle")
+	if _, err = oracle.Explain("SELECT * WHERE { ?s ?p ?o }"); err != ErrNotSupported {
+		t.Errorf("Explain() on oracle returned %v; want ErrNotSupported", err)
+	}
+}
+
+func TestDatasetClausePos(t *testing.T) {
+	tests := []struct {
+		q    string
+		want int
+	}{
+		{"SELECT * WHERE { ?s ?p ?o }", 9},
+		{"ASK { ?s ?p ?o }", 4},
+		{"CONSTRUCT { ?s ?p ?o } WHERE { ?s ?p ?o }", 23},
+		{"DESCRIBE <http://example.org/>", -1},
+	}
+	for _, test := range tests {
+		if got := datasetClausePos(test.q); got != test.want {
+			t.Errorf("datasetClausePos(%q) = %d; want %d", test.q, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package sparql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Expr is a SPARQL expression, for use in FILTERs of queries built with
+// Select. Expressions are built from operands, like Var and Literal, and
+// combined with the functions below, which add parentheses where the
+// precedence of the operators requires them:
+//
+//	Or(Eq(Var("a"), Literal(1)), Lt(Var("b"), Literal(2)))   // ?a = 1 || ?b < 2
+//	And(Or(Var("x"), Var("y")), Bound(Var("z")))            // (?x || ?y) && BOUND(?z)
+type Expr struct {
+	text string
+	prec int
+}
+
+// Expression precedence levels, from loosest to tightest binding.
+const (
+	precOr = iota
+	precAnd
+	precRelational
+	precNumeric
+	precUnary
+	precOperand
+)
+
+// String returns the expression in SPARQL syntax.
+func (e Expr) String() string {
+	return e.text
+}
+
+// wrap parenthesizes the expression if it binds looser than prec.
+func (e Expr) wrap(prec int) string {
+	if e.prec < prec {
+		return "(" + e.text + ")"
+	}
+	return e.text
+}
+
+// Var returns the variable with the given name, with or without a leading ?.
+func Var(name string) Expr {
+	return Expr{variable(name), precOperand}
+}
+
+// IRI returns the IRI as an expression. Characters which may not appear in
+// IRIs are percent-encoded.
+func IRI(iri string) Expr {
+	return Expr{"<" + escapeIRI(iri) + ">", precOperand}
+}
+
+// Raw returns the text as an expression, unchanged. Use it for prefixed names
+// or function calls not covered by this package.
+func Raw(text string) Expr {
+	return Expr{text, precOperand}
+}
+
+// Literal returns the value as a literal, formatted by FormatLiteral. Values
+// FormatLiteral does not support are written as plain strings, as formatted
+// by fmt.Sprint.
+func Literal(v interface{}) Expr {
+	s, err := FormatLiteral(v)
+	if err != nil {
+		s = quoteString(fmt.Sprint(v))
+	}
+	return Expr{s, precOperand}
+}
+
+// Or returns the logical disjunction of the expressions.
+func Or(exprs ...Expr) Expr {
+	return operator(exprs, " || ", precOr)
+}
+
+// And returns the logical conjunction of the expressions.
+func And(exprs ...Expr) Expr {
+	return operator(exprs, " && ", precAnd)
+}
+
+func operator(exprs []Expr, op string, prec int) Expr {
+	parts := make([]string, len(exprs))
+	for i, e := range exprs {
+		parts[i] = e.wrap(prec)
+	}
+	return Expr{strings.Join(parts, op), prec}
+}
+
+// Not returns the logical negation of the expression.
+func Not(e Expr) Expr {
+	return Expr{"!" + e.wrap(precOperand), precUnary}
+}
+
+// Eq returns the comparison a = b.
+func Eq(a, b Expr) Expr { return compare(a, "=", b) }
+
+// Ne returns the comparison a != b.
+func Ne(a, b Expr) Expr { return compare(a, "!=", b) }
+
+// Lt returns the comparison a < b.
+func Lt(a, b Expr) Expr { return compare(a, "<", b) }
+
+// Le returns the comparison a <= b.
+func Le(a, b Expr) Expr { return compare(a, "<=", b) }
+
+// Gt returns the comparison a > b.
+func Gt(a, b Expr) Expr { return compare(a, ">", b) }
+
+// Ge returns the comparison a >= b.
+func Ge(a, b Expr) Expr { return compare(a, ">=", b) }
+
+func compare(a Expr, op string, b Expr) Expr {
+	return Expr{a.wrap(precNumeric) + " " + op + " " + b.wrap(precNumeric), precRelational}
+}
+
+// In returns the expression e IN (list...).
+func In(e Expr, list ...Expr) Expr {
+	return membership(e, "IN", list)
+}
+
+// NotIn returns the expression e NOT IN (list...).
+func NotIn(e Expr, list ...Expr) Expr {
+	return membership(e, "NOT IN", list)
+}
+
+func membership(e Expr, op string, list []Expr) Expr {
+	parts := make([]string, len(list))
+	for i, x := range list {
+		parts[i] = x.text
+	}
+	return Expr{e.wrap(precNumeric) + " " + op + " (" + strings.Join(parts, ", ") + ")", precRelational}
+}
+
+// Regex returns the expression REGEX(e, pattern, flags). Flags may be empty.
+func Regex(e Expr, pattern, flags string) Expr {
+	if flags == "" {
+		return call("REGEX", e, Literal(pattern))
+	}
+	return call("REGEX", e, Literal(pattern), Literal(flags))
+}
+
+// Lang returns the expression LANG(e), the language tag of a literal.
+func Lang(e Expr) Expr { return call("LANG", e) }
+
+// LangMatches returns the expression LANGMATCHES(LANG(e), rng), which is
+// true if the language tag of e matches the language range, e.g. "en" or "*".
+func LangMatches(e Expr, rng string) Expr {
+	return call("LANGMATCHES", Lang(e), Literal(rng))
+}
+
+// Datatype returns the expression DATATYPE(e), the datatype IRI of a literal.
+func Datatype(e Expr) Expr { return call("DATATYPE", e) }
+
+// Str returns the expression STR(e), the lexical form of a term.
+func Str(e Expr) Expr { return call("STR", e) }
+
+// Bound returns the expression BOUND(v), which is true if v is bound.
+func Bound(v Expr) Expr { return call("BOUND", v) }
+
+// Call returns the call of the named function with the arguments, e.g.
+// Call("CONTAINS", Var("name"), Literal("Smith")) or a function IRI.
+func Call(fn string, args ...Expr) Expr {
+	return call(fn, args...)
+}
+
+func call(fn string, args ...Expr) Expr {
+	parts := make([]string, len(args))
+	for i, a := range args {
+		parts[i] = a.text
+	}
+	return Expr{fn + "(" + strings.Join(parts, ", ") + ")", precOperand}
+}
+
+// Exists returns the expression EXISTS { patterns }.
+func Exists(patterns ...Pattern) Expr {
+	return exists("EXISTS ", patterns)
+}
+
+// NotExists returns the expression NOT EXISTS { patterns }.
+func NotExists(patterns ...Pattern) Expr {
+	return exists("NOT EXISTS ", patterns)
+}
+
+func exists(op string, patterns []Pattern) Expr {
+	var b strings.Builder
+	b.WriteString(op)
+	writeGroup(&b, patterns)
+	return Expr{b.String(), precOperand}
+}
+
+// filter is a FILTER pattern.
+type filter Expr
+
+func (f filter) writePattern(b *strings.Builder) {
+	b.WriteString("FILTER (" + f.text + ")")
+}
+
+// Filter returns a FILTER pattern restricting the solutions of the group it
+// is in to those for which the expression is true.
+func Filter(e Expr) Pattern {
+	return filter(e)
+}
@@ -0,0 +1,48 @@
+package sparql
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestExpr(t *testing.T) {
+	tests := []struct {
+		got  Expr
+		want string
+	}{
+		{Or(Eq(Var("a"), Literal(1)), Lt(Var("?b"), Literal(2))), "?a = 1 || ?b < 2"},
+		{And(Or(Var("x"), Var("y")), Bound(Var("z"))), "(?x || ?y) && BOUND(?z)"},
+		{Or(And(Var("x"), Var("y")), Var("z")), "?x && ?y || ?z"},
+		{Not(And(Var("x"), Var("y"))), "!(?x && ?y)"},
+		{Not(Bound(Var("x"))), "!BOUND(?x)"},
+		{Eq(Eq(Var("a"), Var("b")), Literal(true)), "(?a = ?b) = true"},
+		{Eq(Lang(Var("l")), Literal("en")), `LANG(?l) = "en"`},
+		{Eq(Datatype(Var("l")), IRI(xsdNS+"integer")), "DATATYPE(?l) = <http://www.w3.org/2001/XMLSchema#integer>"},
+		{Eq(Var("s"), IRI("x> } ; DROP ALL ; <y")), "?s = <x%3E%20%7D%20;%20DROP%20ALL%20;%20%3Cy>"},
+		{Regex(Str(Var("s")), `^a"b\d`, "i"), `REGEX(STR(?s), "^a\"b\\d", "i")`},
+		{In(Var("x"), Literal(1), Raw("ex:a")), "?x IN (1, ex:a)"},
+		{NotIn(Var("x"), Literal("a\nb")), `?x NOT IN ("a\nb")`},
+		{Ge(Var("d"), Literal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))), `?d >= "2020-01-02T03:04:05Z"^^<http://www.w3.org/2001/XMLSchema#dateTime>`},
+		{Gt(Var("f"), Literal(1.5)), "?f > 1.5e+00"},
+		{Ne(Var("f"), Literal(math.NaN())), `?f != "NaN"^^<http://www.w3.org/2001/XMLSchema#double>`},
+		{LangMatches(Var("l"), "*"), `LANGMATCHES(LANG(?l), "*")`},
+		{NotExists(Triple("?s", "a", "ex:Hidden")), "NOT EXISTS { ?s a ex:Hidden . }"},
+	}
+	for _, test := range tests {
+		if test.got.String() != test.want {
+			t.Errorf("Got expression %s; want %s", test.got, test.want)
+		}
+	}
+}
+
+func TestSelectFilter(t *testing.T) {
+	q := Select("?s").Where(
+		Triple("?s", "rdfs:label", "?l"),
+		Filter(And(LangMatches(Var("l"), "en"), Regex(Var("l"), "^The", ""))),
+	)
+	want := `SELECT ?s WHERE { ?s rdfs:label ?l . FILTER (LANGMATCHES(LANG(?l), "en") && REGEX(?l, "^The")) }`
+	if got := q.String(); got != want {
+		t.Errorf("Got query:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,285 @@
+package sparql
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Federation queries several repositories as if they were one, for data
+// spread across stores. It handles queries whose WHERE clause is a basic
+// graph pattern: triple patterns separated by dots, without predicate or
+// object lists, filters or nested groups.
+type Federation struct {
+	repos []*Repo
+}
+
+// NewFederation returns a Federation of the repositories. The first one is
+// the coordinator of QueryServices. A Federation of no repositories returns
+// errors for all queries.
+func NewFederation(repos ...*Repo) *Federation {
+	return &Federation{repos: repos}
+}
+
+var errNoRepos = errors.New("sparql: federation has no repositories")
+
+// bgpQuery is a SELECT query with a basic graph pattern.
+type bgpQuery struct {
+	prologue string // the query up to the query form
+	head     string // from the query form to the WHERE clause
+	tail     string // the solution modifiers
+	distinct bool
+	vars     []string // the projection, or nil for *
+	patterns []string // the triple patterns, without the dots
+	limit    int      // -1 for no limit
+	offset   int
+	other    bool // whether there are other solution modifiers
+}
+
+// parseBGP parses a SELECT query with a basic graph pattern.
+func parseBGP(q string) (*bgpQuery, error) {
+	toks, err := lex(q)
+	if err != nil {
+		return nil, err
+	}
+	i := skipPrologue(toks)
+	if i == len(toks) || !toks[i].is("SELECT") {
+		return nil, errors.New("sparql: federation supports SELECT queries only")
+	}
+	start := whereStart(toks, i)
+	if start < 0 {
+		return nil, errors.New("sparql: query has no WHERE clause")
+	}
+	end := closing(toks, start)
+	b := bgpQuery{
+		prologue: q[:toks[i].pos],
+		head:     q[toks[i].pos:toks[start].pos],
+		tail:     q[toks[end].pos+1:],
+		limit:    -1,
+	}
+
+	for _, t := range toks[i+1 : start] {
+		switch {
+		case t.is("DISTINCT") || t.is("REDUCED"):
+			b.distinct = true
+		case t.kind == tokVar:
+			b.vars = append(b.vars, "?"+t.text[1:])
+		case t.is("*") || t.is("WHERE"):
+		default:
+			return nil, fmt.Errorf("sparql: federation does not support %q in the projection", t.text)
+		}
+	}
+
+	first := start + 1
+	for j := first; j <= end; j++ {
+		t := toks[j]
+		switch {
+		case t.is(".") || j == end:
+			if j-first >= 3 {
+				b.patterns = append(b.patterns, strings.TrimSpace(q[toks[first].pos:t.pos]))
+			} else if j > first {
+				return nil, &SyntaxError{toks[first].pos, "incomplete triple pattern"}
+			}
+			first = j + 1
+		case t.kind == tokPunct || t.kind == tokWord && !t.is("a") && !t.is("true") && !t.is("false"):
+			return nil, fmt.Errorf("sparql: federation does not support %q in the WHERE clause", t.text)
+		}
+	}
+
+	mods := toks[end+1:]
+	for j := 0; j < len(mods); j += 2 {
+		if j+1 == len(mods) || mods[j+1].kind != tokNumber {
+			b.other = true
+			break
+		}
+		n, _ := strconv.Atoi(mods[j+1].text)
+		switch {
+		case mods[j].is("LIMIT"):
+			b.limit = n
+		case mods[j].is("OFFSET"):
+			b.offset = n
+		default:
+			b.other = true
+		}
+	}
+	return &b, nil
+}
+
+// Services rewrites the query so that every triple pattern is matched
+// against all repositories of the federation, using SERVICE clauses for
+// all but the first one. The coordinating store must be able to reach the
+// endpoints of the others.
+func (f *Federation) Services(q string) (string, error) {
+	if len(f.repos) == 0 {
+		return "", errNoRepos
+	}
+	b, err := parseBGP(q)
+	if err != nil {
+		return "", err
+	}
+	var w strings.Builder
+	w.WriteString(b.prologue + b.head + "{")
+	for _, t := range b.patterns {
+		w.WriteString(" { " + t + " . }")
+		for _, r := range f.repos[1:] {
+			fmt.Fprintf(&w, " UNION { SERVICE <%s> { %s . } }", r.endpoint, t)
+		}
+	}
+	w.WriteString(" }" + b.tail)
+	return w.String(), nil
+}
+
+// QueryServices rewrites the query with Services, and performs it on the
+// first repository of the federation.
+func (f *Federation) QueryServices(q string, options ...func(*Repo) error) (*Results, error) {
+	fq, err := f.Services(q)
+	if err != nil {
+		return nil, err
+	}
+	return f.repos[0].Query(fq, options...)
+}
+
+// Query performs the query across the repositories of the federation, for
+// stores which can't reach each other. Every triple pattern is queried from
+// all repositories, and the solutions are joined client-side, so queries
+// should have selective patterns. Only LIMIT and OFFSET are supported as
+// solution modifiers. Blank nodes are local to a store, and are joined by
+// their labels.
+func (f *Federation) Query(q string, options ...func(*Repo) error) (*Results, error) {
+	if len(f.repos) == 0 {
+		return nil, errNoRepos
+	}
+	b, err := parseBGP(q)
+	if err != nil {
+		return nil, err
+	}
+	if b.other {
+		return nil, errors.New("sparql: federation supports only LIMIT and OFFSET solution modifiers")
+	}
+
+	solutions := []map[string]binding{{}}
+	var vars []string
+	for _, t := range b.patterns {
+		var matches []map[string]binding
+		for _, r := range f.repos {
+			res, err := r.Query(b.prologue+"SELECT * WHERE { "+t+" . }", options...)
+			if err != nil {
+				return nil, err
+			}
+			matches = append(matches, res.Results.Bindings...)
+			for _, v := range res.Head.Vars {
+				if !contains(vars, v) {
+					vars = append(vars, v)
+				}
+			}
+		}
+		if solutions = hashJoin(solutions, matches); len(solutions) == 0 {
+			break
+		}
+	}
+
+	res := Results{Head: header{Vars: vars}}
+	if b.vars != nil {
+		res.Head.Vars = make([]string, len(b.vars))
+		for i, v := range b.vars {
+			res.Head.Vars[i] = v[1:]
+		}
+		for i, s := range solutions {
+			p := make(map[string]binding)
+			for _, v := range res.Head.Vars {
+				if t, ok := s[v]; ok {
+					p[v] = t
+				}
+			}
+			solutions[i] = p
+		}
+	}
+	if b.distinct {
+		res.Results.Distinct = true
+		seen := make(map[string]bool)
+		unique := solutions[:0]
+		for _, s := range solutions {
+			if k := solutionKey(s, res.Head.Vars); !seen[k] {
+				seen[k] = true
+				unique = append(unique, s)
+			}
+		}
+		solutions = unique
+	}
+	if b.offset > len(solutions) {
+		b.offset = len(solutions)
+	}
+	solutions = solutions[b.offset:]
+	if b.limit >= 0 && b.limit < len(solutions) {
+		solutions = solutions[:b.limit]
+	}
+	res.Results.Bindings = solutions
+	return &res, nil
+}
+
+// hashJoin joins two sets of solutions on their shared variables.
+func hashJoin(left, right []map[string]binding) []map[string]binding {
+	if len(left) == 0 || len(right) == 0 {
+		return nil
+	}
+	var shared []string
+	for v := range right[0] {
+		if _, ok := left[0][v]; ok {
+			shared = append(shared, v)
+		}
+	}
+	sort.Strings(shared)
+
+	index := make(map[string][]map[string]binding)
+	for _, s := range right {
+		k := solutionKey(s, shared)
+		index[k] = append(index[k], s)
+	}
+	var joined []map[string]binding
+	for _, l := range left {
+		for _, r := range index[solutionKey(l, shared)] {
+			s := make(map[string]binding, len(l)+len(r))
+			for v, t := range l {
+				s[v] = t
+			}
+			for v, t := range r {
+				s[v] = t
+			}
+			joined = append(joined, s)
+		}
+	}
+	return joined
+}
+
+// solutionKey returns a key identifying the values of the variables in the
+// solution, treating equivalent serializations of literals as equal.
+func solutionKey(s map[string]binding, vars []string) string {
+	var k strings.Builder
+	for _, v := range vars {
+		t, ok := s[v]
+		if !ok {
+			k.WriteString("\x01")
+			continue
+		}
+		typ, dt := t.Type, t.DataType
+		if typ == "typed-literal" {
+			typ = "literal"
+		}
+		if dt == xsdNS+"string" && t.Lang == "" {
+			dt = ""
+		}
+		fmt.Fprintf(&k, "%s\x00%s\x00%s\x00%s\x01", typ, t.Value, strings.ToLower(t.Lang), dt)
+	}
+	return k.String()
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package sparql
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFederation(t *testing.T) {
+	store := func(responses map[string]string) *Repo {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			for pred, res := range responses {
+				if strings.Contains(req.FormValue("query"), pred) {
+					w.Write([]byte(res))
+					return
+				}
+			}
+			w.Write([]byte(testEmptyResults))
+		}))
+		t.Cleanup(ts.Close)
+		repo, err := NewRepo(ts.URL, "fuseki")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return repo
+	}
+	people := store(map[string]string{
+		"foaf:name": `{"head": {"vars": ["q", "name"]}, "results": {"bindings": [
+			{"q": {"type": "uri", "value": "http://example.org/bob"}, "name": {"type": "literal", "value": "Bob"}},
+			{"q": {"type": "uri", "value": "http://example.org/eve"}, "name": {"type": "literal", "value": "Eve"}}
+		]}}`,
+	})
+	graph := store(map[string]string{
+		"foaf:knows": `{"head": {"vars": ["p", "q"]}, "results": {"bindings": [
+			{"p": {"type": "uri", "value": "http://example.org/alice"}, "q": {"type": "uri", "value": "http://example.org/bob"}},
+			{"p": {"type": "uri", "value": "http://example.org/carol"}, "q": {"type": "uri", "value": "http://example.org/bob"}},
+			{"p": {"type": "uri", "value": "http://example.org/alice"}, "q": {"type": "uri", "value": "http://example.org/dan"}}
+		]}}`,
+	})
+	f := NewFederation(people, graph)
+
+	q := "PREFIX foaf: <http://xmlns.com/foaf/0.1/>\nSELECT DISTINCT ?name WHERE { ?p foaf:knows ?q . ?q foaf:name ?name } LIMIT 10"
+	res, err := f.Query(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Head.Vars) != 1 || res.Head.Vars[0] != "name" {
+		t.Errorf("Got vars %v; want [name]", res.Head.Vars)
+	}
+	if len(res.Results.Bindings) != 1 || res.Results.Bindings[0]["name"].Value != "Bob" {
+		t.Errorf("Got solutions %v; want Bob only", res.Results.Bindings)
+	}
+
+	got, err := f.Services(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "PREFIX foaf: <http://xmlns.com/foaf/0.1/>\nSELECT DISTINCT ?name WHERE {" +
+		" { ?p foaf:knows ?q . } UNION { SERVICE <" + graph.endpoint + "> { ?p foaf:knows ?q . } }" +
+		" { ?q foaf:name ?name . } UNION { SERVICE <" + graph.endpoint + "> { ?q foaf:name ?name . } } } LIMIT 10"
+	if got != want {
+		t.Errorf("Got query:\n%s\nwant:\n%s", got, want)
+	}
+
+	for _, q := range []string{
+		"SELECT ?s WHERE { ?s ?p ?o ; ?q ?r }",
+		"SELECT ?s WHERE { ?s ?p ?o FILTER(?o > 1) }",
+		"SELECT ?s WHERE { OPTIONAL { ?s ?p ?o } }",
+		"ASK { ?s ?p ?o }",
+	} {
+		if _, err := f.Query(q); err == nil {
+			t.Errorf("Got no error for unsupported query %q", q)
+		}
+	}
+	if _, err := f.Query("SELECT ?s WHERE { ?s ?p ?o } ORDER BY ?s"); err == nil {
+		t.Error("Got no error for unsupported solution modifier")
+	}
+
+	empty := NewFederation()
+	if _, err := empty.Query(q); err == nil {
+		t.Error("Got no error for Query of empty federation")
+	}
+	if _, err := empty.QueryServices(q); err == nil {
+		t.Error("Got no error for QueryServices of empty federation")
+	}
+}
@@ -0,0 +1,305 @@
+package sparql
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// ParseResults parses a SPARQL query response in the format given by its
+// media type, which can be any of:
+//   - application/sparql-results+json (or application/json)
+//   - application/sparql-results+xml (or application/xml)
+//   - text/csv
+//   - text/tab-separated-values
+//
+// Responses with any other media type are parsed as JSON.
+func ParseResults(r io.Reader, mediaType string) (*Results, error) {
+	return decodeResults(r, mediaType, nil)
+}
+
+// decodeResults implements ParseResults, calling row (if not nil) for every
+// solution as it is decoded from the JSON format.
+func decodeResults(r io.Reader, mediaType string, row func() error) (*Results, error) {
+	switch resultsFormat(mediaType) {
+	case "xml":
+		return ParseXML(r)
+	case "csv":
+		return ParseCSV(r)
+	case "tsv":
+		return ParseTSV(r)
+	default:
+		return decodeJSON(r, row)
+	}
+}
+
+// resultsFormat returns the results format of the media type, or "" if it
+// is not one of the known formats.
+func resultsFormat(mediaType string) string {
+	mt, _, _ := mime.ParseMediaType(mediaType)
+	switch mt {
+	case "application/sparql-results+json", "application/json":
+		return "json"
+	case "application/sparql-results+xml", "application/xml", "text/xml":
+		return "xml"
+	case "text/csv":
+		return "csv"
+	case "text/tab-separated-values":
+		return "tsv"
+	default:
+		return ""
+	}
+}
+
+type xmlResults struct {
+	Head struct {
+		Variables []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"variable"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+		} `xml:"link"`
+	} `xml:"head"`
+	Results struct {
+		Distinct bool `xml:"distinct,attr"`
+		Ordered  bool `xml:"ordered,attr"`
+		Results  []struct {
+			Bindings []struct {
+				Name    string  `xml:"name,attr"`
+				URI     *string `xml:"uri"`
+				BNode   *string `xml:"bnode"`
+				Literal *struct {
+					Value    string `xml:",chardata"`
+					Lang     string `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+					DataType string `xml:"datatype,attr"`
+				} `xml:"literal"`
+			} `xml:"binding"`
+		} `xml:"result"`
+	} `xml:"results"`
+	Boolean bool `xml:"boolean"`
+}
+
+// ParseXML takes an application/sparql-results+xml response and parses it
+// into a Results struct.
+func ParseXML(r io.Reader) (*Results, error) {
+	var x xmlResults
+	if err := xml.NewDecoder(r).Decode(&x); err != nil {
+		return nil, err
+	}
+
+	res := Results{Boolean: x.Boolean}
+	for _, v := range x.Head.Variables {
+		res.Head.Vars = append(res.Head.Vars, v.Name)
+	}
+	for _, l := range x.Head.Links {
+		res.Head.Link = append(res.Head.Link, l.Href)
+	}
+	res.Results.Distinct = x.Results.Distinct
+	res.Results.Ordered = x.Results.Ordered
+
+	for _, xr := range x.Results.Results {
+		solution := make(map[string]binding)
+		for _, xb := range xr.Bindings {
+			var b binding
+			switch {
+			case xb.URI != nil:
+				b = binding{Type: "uri", Value: *xb.URI}
+			case xb.BNode != nil:
+				b = binding{Type: "bnode", Value: *xb.BNode}
+			case xb.Literal != nil && xb.Literal.DataType != "":
+				b = binding{Type: "typed-literal", Value: xb.Literal.Value, DataType: xb.Literal.DataType}
+			case xb.Literal != nil:
+				b = binding{Type: "literal", Value: xb.Literal.Value, Lang: xb.Literal.Lang}
+			default:
+				return nil, fmt.Errorf("sparql: binding %q has no value", xb.Name)
+			}
+			solution[xb.Name] = b
+		}
+		res.Results.Bindings = append(res.Results.Bindings, solution)
+	}
+
+	return &res, nil
+}
+
+// ParseCSV takes a text/csv response and parses it into a Results struct.
+// The CSV results format does not distinguish between IRIs and literals,
+// nor does it carry datatypes or language tags: values which look like
+// absolute IRIs are taken to be IRIs, values starting with "_:" blank nodes,
+// and all other values plain literals.
+func ParseCSV(r io.Reader) (*Results, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	return fromRecords(records, func(s string) (binding, error) {
+		switch {
+		case strings.HasPrefix(s, "_:"):
+			return binding{Type: "bnode", Value: s[2:]}, nil
+		case looksLikeIRI(s):
+			return binding{Type: "uri", Value: s}, nil
+		default:
+			return binding{Type: "literal", Value: s}, nil
+		}
+	})
+}
+
+// ParseTSV takes a text/tab-separated-values response and parses it into a
+// Results struct.
+func ParseTSV(r io.Reader) (*Results, error) {
+	var records [][]string
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1<<24)
+	for s.Scan() {
+		records = append(records, strings.Split(strings.TrimSuffix(s.Text(), "\r"), "\t"))
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return fromRecords(records, parseTSVTerm)
+}
+
+// fromRecords builds Results from the rows of the CSV and TSV results
+// formats, which only differ in how terms are encoded. The first row holds
+// the variable names.
+func fromRecords(records [][]string, term func(string) (binding, error)) (*Results, error) {
+	var res Results
+	if len(records) == 0 {
+		return &res, nil
+	}
+
+	for _, v := range records[0] {
+		res.Head.Vars = append(res.Head.Vars, strings.TrimLeft(v, "?$"))
+	}
+
+	for _, record := range records[1:] {
+		solution := make(map[string]binding)
+		for i, v := range record {
+			if v == "" || i >= len(res.Head.Vars) {
+				continue
+			}
+			b, err := term(v)
+			if err != nil {
+				return nil, err
+			}
+			solution[res.Head.Vars[i]] = b
+		}
+		res.Results.Bindings = append(res.Results.Bindings, solution)
+	}
+
+	return &res, nil
+}
+
+const xsdNS = "http://www.w3.org/2001/XMLSchema#"
+
+// parseTSVTerm parses a term in the Turtle-like syntax of the TSV results format.
+func parseTSVTerm(s string) (binding, error) {
+	switch {
+	case strings.HasPrefix(s, "<") && strings.HasSuffix(s, ">"):
+		return binding{Type: "uri", Value: s[1 : len(s)-1]}, nil
+	case strings.HasPrefix(s, "_:"):
+		return binding{Type: "bnode", Value: s[2:]}, nil
+	case strings.HasPrefix(s, `"`):
+		end := strings.LastIndex(s, `"`)
+		if end == 0 {
+			return binding{}, fmt.Errorf("sparql: unterminated literal in TSV results: %s", s)
+		}
+		v, err := unescapeLiteral(s[1:end])
+		if err != nil {
+			return binding{}, err
+		}
+		switch rest := s[end+1:]; {
+		case rest == "":
+			return binding{Type: "literal", Value: v}, nil
+		case strings.HasPrefix(rest, "@"):
+			return binding{Type: "literal", Value: v, Lang: rest[1:]}, nil
+		case strings.HasPrefix(rest, "^^<") && strings.HasSuffix(rest, ">"):
+			return binding{Type: "typed-literal", Value: v, DataType: rest[3 : len(rest)-1]}, nil
+		default:
+			return binding{}, fmt.Errorf("sparql: invalid literal in TSV results: %s", s)
+		}
+	case s == "true" || s == "false":
+		return binding{Type: "typed-literal", Value: s, DataType: xsdNS + "boolean"}, nil
+	}
+
+	if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return binding{Type: "typed-literal", Value: s, DataType: xsdNS + "integer"}, nil
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		if strings.ContainsAny(s, "eE") {
+			return binding{Type: "typed-literal", Value: s, DataType: xsdNS + "double"}, nil
+		}
+		return binding{Type: "typed-literal", Value: s, DataType: xsdNS + "decimal"}, nil
+	}
+	return binding{}, fmt.Errorf("sparql: invalid term in TSV results: %s", s)
+}
+
+// unescapeLiteral resolves the escape sequences of a Turtle string literal.
+func unescapeLiteral(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i++; i == len(s) {
+			return "", errors.New("sparql: invalid escape sequence at end of literal")
+		}
+		switch c := s[i]; c {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case '"', '\'', '\\':
+			b.WriteByte(c)
+		case 'u', 'U':
+			n := 4
+			if c == 'U' {
+				n = 8
+			}
+			if i+n >= len(s) {
+				return "", fmt.Errorf("sparql: invalid escape sequence \\%c in literal", c)
+			}
+			code, err := strconv.ParseUint(s[i+1:i+1+n], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("sparql: invalid escape sequence \\%s in literal", s[i:i+1+n])
+			}
+			b.WriteRune(rune(code))
+			i += n
+		default:
+			return "", fmt.Errorf("sparql: invalid escape sequence \\%c in literal", c)
+		}
+	}
+	return b.String(), nil
+}
+
+// looksLikeIRI reports whether s has the form of an absolute IRI.
+func looksLikeIRI(s string) bool {
+	i := strings.Index(s, ":")
+	if i < 1 || strings.ContainsAny(s, " \t\n\"<>{}") {
+		return false
+	}
+	for j, c := range s[:i] {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case j > 0 && ('0' <= c && c <= '9' || c == '+' || c == '-' || c == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,157 @@
+package sparql
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/knakk/rdf"
+)
+
+const testXMLResults = `<?xml version="1.0"?>
+<sparql xmlns="http://www.w3.org/2005/sparql-results#">
+  <head>
+    <variable name="x"/>
+    <variable name="hpage"/>
+    <variable name="name"/>
+    <variable name="age"/>
+  </head>
+  <results>
+    <result>
+      <binding name="x"><bnode>r1</bnode></binding>
+      <binding name="hpage"><uri>http://work.example.org/alice/</uri></binding>
+      <binding name="name"><literal>Alice</literal></binding>
+      <binding name="age"><literal datatype="http://www.w3.org/2001/XMLSchema#integer">17</literal></binding>
+    </result>
+    <result>
+      <binding name="x"><bnode>r2</bnode></binding>
+      <binding name="name"><literal xml:lang="en">Bob</literal></binding>
+    </result>
+  </results>
+</sparql>`
+
+const testTSVResults = "?x\t?hpage\t?name\t?age\n" +
+	"_:r1\t<http://work.example.org/alice/>\t\"Alice\"\t17\n" +
+	"_:r2\t\t\"Bob\\tthe builder\"@en\t\"43\"^^<http://www.w3.org/2001/XMLSchema#integer>\n"
+
+const testCSVResults = "x,hpage,name,age\r\n" +
+	"_:r1,http://work.example.org/alice/,Alice,17\r\n" +
+	"_:r2,,\"Bob, the builder\",43\r\n"
+
+func TestParseXML(t *testing.T) {
+	res, err := ParseXML(strings.NewReader(testXMLResults))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Head.Vars) != 4 {
+		t.Errorf("Got %d vars in head, want 4", len(res.Head.Vars))
+	}
+
+	s := res.Solutions()
+	if len(s) != 2 {
+		t.Fatalf("Got %d solutions, want 2", len(s))
+	}
+	if s[0]["hpage"].Type() != rdf.TermIRI {
+		t.Errorf("Got hpage of type %v, want IRI", s[0]["hpage"].Type())
+	}
+	if v, _ := s[0]["age"].(rdf.Literal).Typed(); v != 17 {
+		t.Errorf("Got age %v, want 17", v)
+	}
+	if lang := s[1]["name"].(rdf.Literal).Lang(); lang != "en" {
+		t.Errorf("Got language %q, want en", lang)
+	}
+}
+
+func TestParseTSV(t *testing.T) {
+	res, err := ParseTSV(strings.NewReader(testTSVResults))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Head.Vars) != 4 || res.Head.Vars[0] != "x" {
+		t.Errorf("Got vars %v, want [x hpage name age]", res.Head.Vars)
+	}
+
+	s := res.Solutions()
+	if len(s) != 2 {
+		t.Fatalf("Got %d solutions, want 2", len(s))
+	}
+	if s[0]["x"].Type() != rdf.TermBlank || s[0]["hpage"].Type() != rdf.TermIRI {
+		t.Errorf("Got terms %v and %v, want blank node and IRI", s[0]["x"], s[0]["hpage"])
+	}
+	if _, ok := s[1]["hpage"]; ok {
+		t.Error("Got binding for unbound hpage")
+	}
+	if name := s[1]["name"].String(); name != "Bob\tthe builder" {
+		t.Errorf("Got name %q, want %q", name, "Bob\tthe builder")
+	}
+	for i := range s {
+		if s[i]["age"].(rdf.Literal).DataType.String() != xsdNS+"integer" {
+			t.Errorf("Got age datatype %v, want xsd:integer", s[i]["age"].(rdf.Literal).DataType)
+		}
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	res, err := ParseCSV(strings.NewReader(testCSVResults))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := res.Solutions()
+	if len(s) != 2 {
+		t.Fatalf("Got %d solutions, want 2", len(s))
+	}
+	if s[0]["hpage"].Type() != rdf.TermIRI {
+		t.Errorf("Got hpage of type %v, want IRI", s[0]["hpage"].Type())
+	}
+	if name := s[1]["name"].String(); name != "Bob, the builder" {
+		t.Errorf("Got name %q, want %q", name, "Bob, the builder")
+	}
+}
+
+func TestQueryDefaultAccept(t *testing.T) {
+	var accept string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		accept = req.Header.Get("Accept")
+		w.Write([]byte(testEmptyResults))
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL, "ontotext")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = repo.Query("SELECT * WHERE { ?s ?p ?o }"); err != nil {
+		t.Fatal(err)
+	}
+	if accept != "application/sparql-results+json" {
+		t.Errorf("Got Accept header %q, want application/sparql-results+json", accept)
+	}
+}
+
+func TestQueryAccept(t *testing.T) {
+	var accept string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		accept = req.Header.Get("Accept")
+		w.Header().Set("Content-Type", "application/sparql-results+xml; charset=utf-8")
+		w.Write([]byte(testXMLResults))
+	}))
+	defer ts.Close()
+
+	const want = "application/sparql-results+xml, application/sparql-results+json;q=0.9"
+	repo, err := NewRepo(ts.URL, "ontotext", Accept(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := repo.Query("SELECT * WHERE { ?s ?p ?o }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if accept != want {
+		t.Errorf("Got Accept header %q, want %q", accept, want)
+	}
+	if len(res.Results.Bindings) != 2 {
+		t.Errorf("Got %d solutions, want 2", len(res.Results.Bindings))
+	}
+}
@@ -0,0 +1,153 @@
+package sparql
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Hedge sends reads to a second endpoint as well, if the primary endpoint has
+// not responded within delay, and uses whichever response arrives first. The
+// other request is then cancelled. It is meant for latency-sensitive reads
+// against replicated stores, where the endpoints serve the same data.
+//
+// Only queries are hedged; updates are always sent to the update endpoint
+// alone. A hedged request is cancelled by closing its connection, which not
+// every store notices while a query is running.
+//
+// Hedged requests are authenticated with the credentials embedded in addr,
+// if any, rather than those of the primary endpoint, and the DialAddress and
+// HostHeader options do not apply to them. The TLS configuration is shared.
+func Hedge(addr string, delay time.Duration) func(*Repo) error {
+	return func(r *Repo) error {
+		addr, user, err := parseEndpoint(addr)
+		if err != nil {
+			return err
+		}
+		r.hedgeEndpoint, r.hedgeUser, r.hedgeDelay = addr, user, delay
+		return nil
+	}
+}
+
+// hedgeHost returns the host and port of the hedge endpoint, if it is on
+// another host than the endpoint.
+func (r *Repo) hedgeHost() string {
+	if h := hostPort(r.hedgeEndpoint); h != hostPort(r.endpoint) {
+		return h
+	}
+	return ""
+}
+
+// hedgeRepo returns the Repo to send hedged requests through, with the
+// overrides of the primary endpoint removed.
+func (r *Repo) hedgeRepo() *Repo {
+	h := r.copy()
+	h.basicAuth, h.host = r.hedgeUser, ""
+	if r.hedgeHost() != "" {
+		h.ctx = context.WithValue(h.ctx, directDial{}, true)
+	}
+	if h.digestUsername != "" || h.digestPassword != "" {
+		h.digestUsername, h.digestPassword = "", ""
+		h.client.Transport = h.roundTripper()
+	}
+	return h
+}
+
+// hedgeResult is the outcome of one of the requests of a hedged read.
+type hedgeResult struct {
+	resp *http.Response
+	err  error
+	i    int
+}
+
+// doHedged sends a read request, hedging it with a request to the second
+// endpoint if configured.
+func (r *Repo) doHedged(req *http.Request) (*http.Response, error) {
+	if r.hedgeEndpoint == "" || !strings.HasPrefix(req.URL.String(), r.endpoint) {
+		return r.do(req, true)
+	}
+
+	u, err := url.Parse(r.hedgeEndpoint + strings.TrimPrefix(req.URL.String(), r.endpoint))
+	if err != nil {
+		return nil, err
+	}
+	hedged := req.Clone(req.Context())
+	hedged.URL, hedged.Host = u, ""
+	if req.GetBody != nil {
+		if hedged.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make(chan hedgeResult, 2)
+	var cancels []context.CancelFunc
+	send := func(req *http.Request, c *Repo) {
+		ctx, cancel := context.WithCancel(c.ctx)
+		i := len(cancels)
+		cancels = append(cancels, cancel)
+		c.ctx = ctx
+		go func() {
+			resp, err := c.do(req, true)
+			results <- hedgeResult{resp, err, i}
+		}()
+	}
+	// discard drops the response of a request which is not used.
+	discard := func(res hedgeResult) {
+		cancels[res.i]()
+		if res.resp != nil {
+			io.Copy(ioutil.Discard, res.resp.Body)
+			res.resp.Body.Close()
+		}
+	}
+
+	send(req, r.copy())
+	timer := time.NewTimer(r.hedgeDelay)
+	defer timer.Stop()
+
+	var failed *hedgeResult
+	for pending := 1; ; {
+		select {
+		case <-timer.C:
+			if len(cancels) == 1 {
+				send(hedged, r.hedgeRepo())
+				pending++
+			}
+		case res := <-results:
+			pending--
+			if res.err == nil && res.resp.StatusCode == http.StatusOK {
+				for i, cancel := range cancels {
+					if i != res.i {
+						cancel()
+					}
+				}
+				if pending > 0 {
+					go func() { discard(<-results) }()
+				}
+				res.resp.Body = &releasingBody{ReadCloser: res.resp.Body, release: cancels[res.i]}
+				return res.resp, nil
+			}
+
+			// Keep the latest failure, to report it if the other request fails too.
+			if failed != nil {
+				discard(*failed)
+			}
+			failed = &res
+			if len(cancels) == 1 {
+				send(hedged, r.hedgeRepo())
+				pending++
+			}
+			if pending == 0 {
+				if res.resp != nil {
+					res.resp.Body = &releasingBody{ReadCloser: res.resp.Body, release: cancels[res.i]}
+				} else {
+					cancels[res.i]()
+				}
+				return res.resp, res.err
+			}
+		}
+	}
+}
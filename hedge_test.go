@@ -0,0 +1,140 @@
+package sparql
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHedge(t *testing.T) {
+	var primaryDelay atomic.Value
+	primaryDelay.Store(time.Duration(0))
+	cancelled := make(chan struct{}, 1)
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// The server notices the client going away once the body has been read.
+		req.ParseForm()
+		select {
+		case <-time.After(primaryDelay.Load().(time.Duration)):
+			w.Write([]byte(`{"head": {"vars": ["x"]}, "results": {"bindings": [{"x": {"type": "literal", "value": "primary"}}]}}`))
+		case <-req.Context().Done():
+			cancelled <- struct{}{}
+		}
+	}))
+	defer primary.Close()
+
+	var hedged int32
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&hedged, 1)
+		if req.FormValue("query") == "" {
+			t.Error("Got hedged request without query")
+		}
+		w.Write([]byte(`{"head": {"vars": ["x"]}, "results": {"bindings": [{"x": {"type": "literal", "value": "secondary"}}]}}`))
+	}))
+	defer secondary.Close()
+
+	repo, err := NewRepo(primary.URL, "fuseki", Hedge(secondary.URL, 20*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A fast primary is not hedged.
+	res, err := repo.Query("SELECT * WHERE { ?s ?p ?x }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.Solutions()[0]["x"].String(); got != "primary" || atomic.LoadInt32(&hedged) != 0 {
+		t.Errorf("Got %q after %d hedged requests; want primary and none", got, hedged)
+	}
+
+	// A slow primary is hedged, and cancelled once the secondary answers.
+	primaryDelay.Store(time.Second)
+	res, err = repo.Query("SELECT * WHERE { ?s ?p ?x }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.Solutions()[0]["x"].String(); got != "secondary" || atomic.LoadInt32(&hedged) != 1 {
+		t.Errorf("Got %q after %d hedged requests; want secondary and 1", got, hedged)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(500 * time.Millisecond):
+		t.Error("Primary request was not cancelled")
+	}
+
+	// Updates are never hedged.
+	primaryDelay.Store(100 * time.Millisecond)
+	if _, err = repo.Update("INSERT DATA { <http://example.org/s> <http://example.org/p> 1 }"); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&hedged); n != 1 {
+		t.Errorf("Got %d hedged requests; want 1", n)
+	}
+}
+
+func TestHedgeFailure(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "overloaded", http.StatusInternalServerError)
+	}))
+	defer primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(testEmptyResults))
+	}))
+	defer secondary.Close()
+
+	// A failed primary is hedged right away rather than after the delay.
+	repo, err := NewRepo(primary.URL, "fuseki", Hedge(secondary.URL, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = repo.Query("SELECT * WHERE { ?s ?p ?o }"); err != nil {
+		t.Fatal(err)
+	}
+
+	// If both fail, the error is reported.
+	repo, err = NewRepo(primary.URL, "fuseki", Hedge(primary.URL, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = repo.Query("SELECT * WHERE { ?s ?p ?o }"); err == nil {
+		t.Error("Got no error; want the failure of the endpoints")
+	}
+
+	if _, err = NewRepo(primary.URL, "fuseki", Hedge("localhost:3030", time.Second)); err == nil {
+		t.Error("Got no error for invalid hedge endpoint")
+	}
+}
+
+func TestHedgeOverrides(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		req.ParseForm()
+		<-req.Context().Done()
+	}))
+	defer primary.Close()
+	var host, user string
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		host = req.Host
+		user, _, _ = req.BasicAuth()
+		w.Write([]byte(testEmptyResults))
+	}))
+	defer secondary.Close()
+
+	p, _ := url.Parse(primary.URL)
+	s, _ := url.Parse(secondary.URL)
+	repo, err := NewRepo("http://primary.example.org:"+p.Port()+"/sparql", "fuseki",
+		DialAddress(p.Hostname()), HostHeader("sparql.example.org"), BasicAuth("primary", "secret"),
+		Hedge("http://replica:pass@"+s.Host+"/sparql", 10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The hedged request goes to the replica, as the replica.
+	if _, err = repo.Query("SELECT * WHERE { ?s ?p ?o }"); err != nil {
+		t.Fatal(err)
+	}
+	if host != s.Host || user != "replica" {
+		t.Errorf("Got hedged request to %q as %q; want %q as replica", host, user, s.Host)
+	}
+}
@@ -0,0 +1,87 @@
+package sparql
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/url"
+)
+
+// DialAddress makes Repo connect to addr, a host or IP address with an
+// optional port, instead of the host of the endpoint. Requests still carry
+// the host of the endpoint in the Host header and as the TLS server name, and
+// the certificate of the server is verified against it, e.g. to reach an
+// endpoint behind a shared ingress, or a staging server presenting production
+// certificates. If addr has no port, the port of the endpoint is used.
+//
+// All connections of Repo go to addr, including those to the update endpoint,
+// but not those to the endpoint given to Hedge, if it is on another host.
+func DialAddress(addr string) func(*Repo) error {
+	return func(r *Repo) error {
+		if err := r.repoScoped("DialAddress"); err != nil {
+			return err
+		}
+		r.setDialer(func(d *dialer) { d.addr = addr })
+		return nil
+	}
+}
+
+// ServerName sets the name sent as TLS server name (SNI) and used to verify
+// the certificate of the server, instead of the host of the endpoint. Give it
+// after the TLSConfig option, which replaces the whole TLS configuration.
+func ServerName(name string) func(*Repo) error {
+	return func(r *Repo) error {
+		if err := r.repoScoped("ServerName"); err != nil {
+			return err
+		}
+		t := r.ownTransport()
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{}
+		} else {
+			t.TLSClientConfig = t.TLSClientConfig.Clone()
+		}
+		t.TLSClientConfig.ServerName = name
+		return nil
+	}
+}
+
+// HostHeader sets the Host header of requests, instead of the host of the
+// endpoint. It does not change the TLS server name; use ServerName for that.
+func HostHeader(host string) func(*Repo) error {
+	return func(r *Repo) error {
+		r.host = host
+		return nil
+	}
+}
+
+// directDial is the context key marking requests the dial address does not
+// apply to.
+type directDial struct{}
+
+// dialAddress returns the address to connect to instead of addr, if any.
+func (d *dialer) dialAddress(ctx context.Context, addr string) string {
+	if d.addr == "" || ctx.Value(directDial{}) != nil {
+		return addr
+	}
+	if _, _, err := net.SplitHostPort(d.addr); err == nil {
+		return d.addr
+	}
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return d.addr
+	}
+	return net.JoinHostPort(d.addr, port)
+}
+
+// hostPort returns the host and port connections to the endpoint go to.
+func hostPort(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	port := u.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[u.Scheme]
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
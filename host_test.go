@@ -0,0 +1,63 @@
+package sparql
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestDialAddress(t *testing.T) {
+	var host, serverName string
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		host, serverName = req.Host, req.TLS.ServerName
+		w.Write([]byte(testEmptyResults))
+	}))
+	defer ts.Close()
+	tlsConfig := ts.Client().Transport.(*http.Transport).TLSClientConfig
+	u, _ := url.Parse(ts.URL)
+
+	// The test certificate is valid for example.com.
+	repo, err := NewRepo("https://example.com:"+u.Port()+"/sparql", "fuseki",
+		TLSConfig(tlsConfig), DialAddress(u.Hostname()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = repo.Query("SELECT * WHERE { ?s ?p ?o }"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "example.com:" + u.Port(); host != want || serverName != "example.com" {
+		t.Errorf("Got Host %q and server name %q; want %q and example.com", host, serverName, want)
+	}
+
+	repo, err = NewRepo(ts.URL, "fuseki", TLSConfig(tlsConfig), ServerName("example.com"),
+		HostHeader("sparql.example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = repo.Query("SELECT * WHERE { ?s ?p ?o }"); err != nil {
+		t.Fatal(err)
+	}
+	if host != "sparql.example.com" || serverName != "example.com" {
+		t.Errorf("Got Host %q and server name %q; want sparql.example.com and example.com", host, serverName)
+	}
+	if tlsConfig.ServerName != "" {
+		t.Error("ServerName modified the TLS configuration given to TLSConfig")
+	}
+}
+
+func TestDialAddressPort(t *testing.T) {
+	ctx := context.Background()
+	d := dialer{addr: "10.0.0.1"}
+	if got := d.dialAddress(ctx, "example.com:443"); got != "10.0.0.1:443" {
+		t.Errorf("Got %q; want 10.0.0.1:443", got)
+	}
+	d.addr = "10.0.0.1:8443"
+	if got := d.dialAddress(ctx, "example.com:443"); got != "10.0.0.1:8443" {
+		t.Errorf("Got %q; want 10.0.0.1:8443", got)
+	}
+	if got := d.dialAddress(context.WithValue(ctx, directDial{}, true), "example.com:443"); got != "example.com:443" {
+		t.Errorf("Got %q; want example.com:443 for direct dial", got)
+	}
+}
@@ -0,0 +1,110 @@
+package sparql
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// IdempotencyKeys attaches a unique Idempotency-Key header to every update,
+// which makes it safe to retry updates on temporary failures (see Retries):
+// where a request failed in a way that leaves it unknown whether the update
+// was applied, like a timeout after it was sent, it is sent again with the
+// same key.
+//
+// Stores don't deduplicate updates by this header themselves, though some
+// gateways do. If ledger is not empty, Repo keeps track of applied updates
+// itself: every update also records its key in the named ledger graph, and
+// before an update is retried, the ledger is consulted to see whether it
+// was applied after all. The ledger grows with every update, and should be
+// pruned by the dates recorded with the keys. Counts of inserted triples in
+// UpdateResult include the ledger entry.
+func IdempotencyKeys(ledger string) func(*Repo) error {
+	return func(r *Repo) error {
+		if ledger != "" {
+			if err := ValidateIRI(ledger); err != nil {
+				return err
+			}
+		}
+		r.idempotencyKeys, r.ledger = true, ledger
+		return nil
+	}
+}
+
+// ledgerCreated is the predicate recording when an update was applied.
+const ledgerCreated = "http://purl.org/dc/terms/created"
+
+// recordInLedger adds an operation recording the key in the ledger graph to
+// the update, if there is a ledger.
+func (r *Repo) recordInLedger(update, key string) string {
+	if r.ledger == "" {
+		return update
+	}
+	return fmt.Sprintf("%s ;\nINSERT DATA { GRAPH <%s> { <urn:uuid:%s> <%s> %s } }",
+		update, r.ledger, key, ledgerCreated, TimeFormat{UTC: true, Precision: 3}.Literal(time.Now()))
+}
+
+// doIdempotent sends an update with an idempotency key, retrying on
+// temporary failures as configured, unless the ledger shows that the update
+// has been applied.
+func (r *Repo) doIdempotent(req *http.Request, key string) (*http.Response, error) {
+	if r.ledger == "" {
+		return r.do(req, true)
+	}
+	for attempt := 0; ; attempt++ {
+		resp, err := r.do(req, false)
+		if attempt >= r.retries || !temporary(resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-time.After(r.retryBackoff << uint(attempt)):
+		case <-r.ctx.Done():
+			return nil, r.ctx.Err()
+		}
+
+		applied, aerr := r.inLedger(key)
+		if aerr != nil {
+			return nil, fmt.Errorf("sparql: update may or may not have been applied: checking ledger: %v", aerr)
+		}
+		if applied {
+			return &http.Response{
+				Status:     "200 OK",
+				StatusCode: http.StatusOK,
+				Header:     http.Header{},
+				Body:       ioutil.NopCloser(strings.NewReader("")),
+			}, nil
+		}
+
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// inLedger reports whether the ledger records the key. The check is not a
+// query of the application: it is sent as is, without the parameters,
+// rewrites and lint hook of Repo, as a default graph given by DefaultGraph
+// for instance would leave out the ledger graph.
+func (r *Repo) inLedger(key string) (bool, error) {
+	c := r.copy()
+	c.params = url.Values{}
+	c.hedgeEndpoint = ""
+	c.maxRows = 0
+	c.progressFunc = nil
+	res, err := c.sendQuery(fmt.Sprintf("ASK { GRAPH <%s> { <urn:uuid:%s> ?p ?o } }", r.ledger, key))
+	if err != nil {
+		return false, err
+	}
+	return res.Boolean, nil
+}
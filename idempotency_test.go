@@ -0,0 +1,149 @@
+package sparql
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyKeys(t *testing.T) {
+	var (
+		keys    []string
+		applied bool
+		reject  bool // fail requests as if the response was lost
+		asks    int
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if u := req.FormValue("update"); u != "" {
+			keys = append(keys, req.Header.Get("Idempotency-Key"))
+			if !strings.Contains(u, "GRAPH <http://example.org/ledger> { <urn:uuid:"+req.Header.Get("Idempotency-Key")+">") {
+				t.Errorf("Got update without ledger entry:\n%s", u)
+			}
+			if reject {
+				reject = false
+				applied = true
+				w.WriteHeader(http.StatusGatewayTimeout)
+			}
+			return
+		}
+		asks++
+		if applied {
+			w.Write([]byte(`{"head": {}, "boolean": true}`))
+		} else {
+			w.Write([]byte(`{"head": {}, "boolean": false}`))
+		}
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL, "fuseki", Retries(2, time.Millisecond), IdempotencyKeys("http://example.org/ledger"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The update was applied, but the response lost: it is not sent again.
+	reject = true
+	if _, err := repo.Update("INSERT DATA { <a> <p> 1 }"); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || asks != 1 {
+		t.Errorf("Got %d updates and %d ledger checks; want 1 and 1", len(keys), asks)
+	}
+
+	// The update was not applied: it is sent again with the same key.
+	keys, asks, applied = nil, 0, false
+	ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.FormValue("update") != "" {
+			keys = append(keys, req.Header.Get("Idempotency-Key"))
+			if len(keys) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+			return
+		}
+		asks++
+		w.Write([]byte(`{"head": {}, "boolean": false}`))
+	})
+	if _, err := repo.Update("INSERT DATA { <a> <p> 1 }"); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0] != keys[1] || keys[0] == "" || asks != 1 {
+		t.Errorf("Got keys %q and %d ledger checks; want the same key twice and 1 check", keys, asks)
+	}
+}
+
+func TestIdempotencyKeysStats(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL, "fuseki", CollectStats(), IdempotencyKeys("http://example.org/ledger"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := repo.Update("INSERT DATA { <a> <p> 1 }"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The keys added to updates do not make a fingerprint each.
+	if stats := repo.Stats(); len(stats) != 1 || stats[0].Count != 3 || strings.Contains(stats[0].Fingerprint, "ledger") {
+		t.Errorf("Got stats %+v; want one fingerprint of the update as given", stats)
+	}
+}
+
+func TestIdempotencyKeysLedgerCheck(t *testing.T) {
+	var updates int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.FormValue("update") != "" {
+			if updates++; updates == 1 {
+				w.WriteHeader(http.StatusGatewayTimeout)
+			}
+			return
+		}
+		// The ledger is only visible without a default graph restriction.
+		applied := req.FormValue("default-graph-uri") == "" && !strings.Contains(req.FormValue("query"), "rewritten")
+		w.Write([]byte(`{"head": {}, "boolean": ` + map[bool]string{true: "true", false: "false"}[applied] + `}`))
+	}))
+	defer ts.Close()
+
+	rewrite := RewriteQuery(func(q string) (string, error) { return q + " # rewritten", nil })
+	repo, err := NewRepo(ts.URL, "fuseki", Retries(2, time.Millisecond), DefaultGraph("http://example.org/data"),
+		rewrite, IdempotencyKeys("http://example.org/ledger"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Update("INSERT DATA { <a> <p> 1 }"); err != nil {
+		t.Fatal(err)
+	}
+	if updates != 1 {
+		t.Errorf("Got %d updates; want the applied update not to be sent again", updates)
+	}
+}
+
+func TestIdempotencyKeysHeaders(t *testing.T) {
+	var tenants [][]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.FormValue("update") != "" {
+			tenants = append(tenants, req.Header["X-Tenant"])
+			if len(tenants) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+			return
+		}
+		w.Write([]byte(`{"head": {}, "boolean": false}`))
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL, "fuseki", Retries(2, time.Millisecond), Header("X-Tenant", "a"),
+		IdempotencyKeys("http://example.org/ledger"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Update("INSERT DATA { <a> <p> 1 }"); err != nil {
+		t.Fatal(err)
+	}
+	// Headers are sent once with every attempt.
+	if len(tenants) != 2 || len(tenants[0]) != 1 || len(tenants[1]) != 1 {
+		t.Errorf("Got X-Tenant headers %q; want one on each of 2 attempts", tenants)
+	}
+}
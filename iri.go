@@ -0,0 +1,163 @@
+package sparql
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// IRIError describes why an IRI is invalid.
+type IRIError struct {
+	IRI    string
+	Offset int // byte offset of the problem in the IRI
+	Msg    string
+}
+
+func (e *IRIError) Error() string {
+	return fmt.Sprintf("sparql: invalid IRI %q at offset %d: %s", e.IRI, e.Offset, e.Msg)
+}
+
+// ValidateIRI checks that iri is an absolute IRI as defined by RFC 3987,
+// which can be written as <iri> in a query: it must have a scheme, and may
+// not contain spaces, control characters or any of <>"{}|\^` unescaped, nor
+// malformed percent-encodings. It returns an *IRIError if it is invalid.
+func ValidateIRI(iri string) error {
+	i := schemeEnd(iri)
+	if i < 0 {
+		return &IRIError{iri, 0, "missing scheme"}
+	}
+	for ; i < len(iri); i++ {
+		c := iri[i]
+		switch {
+		case c >= utf8.RuneSelf:
+			r, n := utf8.DecodeRuneInString(iri[i:])
+			if r == utf8.RuneError && n == 1 {
+				return &IRIError{iri, i, "invalid UTF-8"}
+			}
+			i += n - 1
+		case mustEscape(c):
+			return &IRIError{iri, i, fmt.Sprintf("character %q must be percent-encoded", c)}
+		case c == '%':
+			if i+2 >= len(iri) || !isHex(iri[i+1]) || !isHex(iri[i+2]) {
+				return &IRIError{iri, i, "malformed percent-encoding"}
+			}
+		}
+	}
+	return nil
+}
+
+// NormalizeIRI percent-encodes the characters of iri which may not appear in
+// IRIs, and normalizes it as described by RFC 3986: the scheme and host are
+// lowercased, the hex digits of percent-encodings uppercased, percent-encoded
+// unreserved characters decoded, a default port for http or https removed,
+// and an empty path replaced by "/". Other trailing slashes are significant,
+// and kept. It returns an *IRIError if the result is not a valid IRI.
+func NormalizeIRI(iri string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(iri); i++ {
+		c := iri[i]
+		switch {
+		case c == '%' && i+2 < len(iri) && isHex(iri[i+1]) && isHex(iri[i+2]):
+			if d := unhex(iri[i+1])<<4 | unhex(iri[i+2]); isUnreserved(d) {
+				b.WriteByte(d)
+			} else {
+				b.WriteString(strings.ToUpper(iri[i : i+3]))
+			}
+			i += 2
+		case mustEscape(c):
+			fmt.Fprintf(&b, "%%%02X", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	s := b.String()
+	if err := ValidateIRI(s); err != nil {
+		e := err.(*IRIError)
+		e.IRI = iri
+		return "", e
+	}
+
+	i := schemeEnd(s)
+	scheme := strings.ToLower(s[:i-1])
+	rest := s[i:]
+	if !strings.HasPrefix(rest, "//") {
+		return scheme + ":" + rest, nil
+	}
+	end := strings.IndexAny(rest[2:], "/?#")
+	if end < 0 {
+		end = len(rest)
+	} else {
+		end += 2
+	}
+	authority, path := rest[2:end], rest[end:]
+
+	userinfo := ""
+	if at := strings.LastIndexByte(authority, '@'); at >= 0 {
+		userinfo, authority = authority[:at+1], authority[at+1:]
+	}
+	host, port := authority, ""
+	if colon := strings.LastIndexByte(authority, ':'); colon >= 0 && colon > strings.LastIndexByte(authority, ']') {
+		host, port = authority[:colon], authority[colon:]
+	}
+	if scheme == "http" && port == ":80" || scheme == "https" && port == ":443" || port == ":" {
+		port = ""
+	}
+	if path == "" || path[0] != '/' {
+		path = "/" + path
+	}
+	return scheme + "://" + userinfo + strings.ToLower(host) + port + path, nil
+}
+
+// escapeIRI percent-encodes the characters of iri which may not appear in
+// IRIs, so that it cannot end the <iri> it is written in.
+func escapeIRI(iri string) string {
+	var b strings.Builder
+	for i := 0; i < len(iri); i++ {
+		if c := iri[i]; mustEscape(c) {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// mustEscape reports whether the byte must be percent-encoded in IRIs.
+func mustEscape(c byte) bool {
+	return c <= ' ' || c == 0x7f || strings.IndexByte(`<>"{}|\^`+"`", c) >= 0
+}
+
+// schemeEnd returns the index after the colon ending the scheme of iri, or
+// -1 if it has no valid scheme.
+func schemeEnd(iri string) int {
+	for i := 0; i < len(iri); i++ {
+		c := iri[i]
+		switch {
+		case c == ':' && i > 0:
+			return i + 1
+		case isAlnum(c) && (i > 0 || !isDigit(c)), i > 0 && (c == '+' || c == '-' || c == '.'):
+		default:
+			return -1
+		}
+	}
+	return -1
+}
+
+func isHex(c byte) bool {
+	return isDigit(c) || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case isDigit(c):
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
+
+func isUnreserved(c byte) bool {
+	return isAlnum(c) || c == '-' || c == '.' || c == '_' || c == '~'
+}
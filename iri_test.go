@@ -0,0 +1,65 @@
+package sparql
+
+import "testing"
+
+func TestValidateIRI(t *testing.T) {
+	valid := []string{
+		"http://example.org/",
+		"urn:isbn:0451450523",
+		"http://example.org/caf%C3%A9?q=1#frag",
+		"http://例え.jp/パス",
+		"mailto:user@example.org",
+	}
+	for _, iri := range valid {
+		if err := ValidateIRI(iri); err != nil {
+			t.Errorf("Got error for valid IRI %q: %v", iri, err)
+		}
+	}
+
+	invalid := []struct {
+		iri    string
+		offset int
+	}{
+		{"example.org/a", 0},
+		{"1http://example.org/", 0},
+		{"http://example.org/a b", 20},
+		{"http://example.org/a>", 20},
+		{"http://example.org/{x}", 19},
+		{"http://example.org/%2", 19},
+		{"http://example.org/%zz", 19},
+		{"http://example.org/\xff", 19},
+	}
+	for _, test := range invalid {
+		err := ValidateIRI(test.iri)
+		if e, ok := err.(*IRIError); !ok || e.Offset != test.offset {
+			t.Errorf("Got error %v for %q; want IRIError at offset %d", err, test.iri, test.offset)
+		}
+	}
+}
+
+func TestNormalizeIRI(t *testing.T) {
+	tests := []struct {
+		iri, want string
+	}{
+		{"HTTP://Example.ORG", "http://example.org/"},
+		{"http://example.org:80/a/", "http://example.org/a/"},
+		{"https://example.org:443?q", "https://example.org/?q"},
+		{"https://example.org:8443/", "https://example.org:8443/"},
+		{"http://User@Example.org/Path", "http://User@example.org/Path"},
+		{"http://example.org/%7euser/%2f%c3%a9", "http://example.org/~user/%2F%C3%A9"},
+		{"http://example.org/a b<c>", "http://example.org/a%20b%3Cc%3E"},
+		{"http://[::1]:80/", "http://[::1]/"},
+		{"URN:ISBN:0451450523", "urn:ISBN:0451450523"},
+	}
+	for _, test := range tests {
+		got, err := NormalizeIRI(test.iri)
+		if err != nil {
+			t.Errorf("Got error for %q: %v", test.iri, err)
+		} else if got != test.want {
+			t.Errorf("Got %q for %q; want %q", got, test.iri, test.want)
+		}
+	}
+	if _, err := NormalizeIRI("no scheme"); err == nil {
+		t.Error("Got no error for relative IRI")
+	}
+}
@@ -0,0 +1,213 @@
+package sparql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tokenKind is the kind of a lexical token of a SPARQL query.
+type tokenKind int
+
+const (
+	tokIRI     tokenKind = iota // <http://example.org/>
+	tokPName                    // ex:name, or ex: alone
+	tokVar                      // ?x or $x
+	tokBlank                    // _:b0
+	tokString                   // "literal", including the quotes
+	tokLangTag                  // @en
+	tokNumber                   // 42, 4.2, 4e2
+	tokWord                     // keywords, function names, a, true and false
+	tokPunct                    // { } ( ) [ ] . ; ,
+	tokOp                       // operators, including ^^ and path operators
+)
+
+// token is a lexical token of a SPARQL query.
+type token struct {
+	kind tokenKind
+	text string
+	pos  int // byte offset in the query
+}
+
+// is reports whether the token is the given keyword or punctuation,
+// ignoring case.
+func (t token) is(text string) bool {
+	return (t.kind == tokWord || t.kind == tokPunct || t.kind == tokOp) && strings.EqualFold(t.text, text)
+}
+
+// SyntaxError is returned for queries which are not valid SPARQL.
+type SyntaxError struct {
+	Offset int // byte offset in the query
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("sparql: syntax error at offset %d: %s", e.Offset, e.Msg)
+}
+
+// lex splits a SPARQL query into tokens, leaving out whitespace and comments.
+func lex(q string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(q); {
+		c := q[i]
+		start := i
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case c == '#':
+			for i < len(q) && q[i] != '\n' {
+				i++
+			}
+			continue
+		case c == '<':
+			if j := iriEnd(q, i); j > 0 {
+				toks = append(toks, token{tokIRI, q[i:j], i})
+				i = j
+				continue
+			}
+			i++
+			if i < len(q) && q[i] == '=' {
+				i++
+			}
+			toks = append(toks, token{tokOp, q[start:i], start})
+			continue
+		case c == '"' || c == '\'':
+			j, err := stringEnd(q, i)
+			if err != nil {
+				return nil, err
+			}
+			toks = append(toks, token{tokString, q[i:j], i})
+			i = j
+			continue
+		case c == '@' && len(toks) > 0 && toks[len(toks)-1].kind == tokString:
+			i++
+			for i < len(q) && (isAlnum(q[i]) || q[i] == '-') {
+				i++
+			}
+			toks = append(toks, token{tokLangTag, q[start:i], start})
+			continue
+		case (c == '?' || c == '$') && i+1 < len(q) && isNameByte(q[i+1]):
+			i++
+			for i < len(q) && isNameByte(q[i]) {
+				i++
+			}
+			toks = append(toks, token{tokVar, q[start:i], start})
+			continue
+		case isDigit(c) || c == '.' && i+1 < len(q) && isDigit(q[i+1]):
+			i = numberEnd(q, i)
+			toks = append(toks, token{tokNumber, q[start:i], start})
+			continue
+		case c == '_' && i+1 < len(q) && q[i+1] == ':':
+			i = nameEnd(q, i+2)
+			toks = append(toks, token{tokBlank, q[start:i], start})
+			continue
+		case isNameStart(c) || c == ':':
+			i = nameEnd(q, i)
+			kind := tokWord
+			if strings.Contains(q[start:i], ":") {
+				kind = tokPName
+			}
+			toks = append(toks, token{kind, q[start:i], start})
+			continue
+		case strings.IndexByte("{}()[].;,", c) >= 0:
+			toks = append(toks, token{tokPunct, q[i : i+1], i})
+			i++
+			continue
+		}
+
+		for _, op := range []string{"^^", "&&", "||", "!=", ">=", "<="} {
+			if strings.HasPrefix(q[i:], op) {
+				toks = append(toks, token{tokOp, op, i})
+				i += len(op)
+				break
+			}
+		}
+		if i > start {
+			continue
+		}
+		if strings.IndexByte("=<>!+-*/|^?", c) >= 0 {
+			toks = append(toks, token{tokOp, q[i : i+1], i})
+			i++
+			continue
+		}
+		return nil, &SyntaxError{i, fmt.Sprintf("unexpected character %q", c)}
+	}
+	return toks, nil
+}
+
+// iriEnd returns the end of the IRI reference starting at i, or -1 if the
+// '<' at i does not start an IRI, but is an operator.
+func iriEnd(q string, i int) int {
+	for j := i + 1; j < len(q); j++ {
+		switch c := q[j]; {
+		case c == '>':
+			return j + 1
+		case c <= ' ' || strings.IndexByte("<\"{}|^`\\", c) >= 0:
+			return -1
+		}
+	}
+	return -1
+}
+
+// stringEnd returns the end of the string literal starting at i.
+func stringEnd(q string, i int) (int, error) {
+	quote := q[i : i+1]
+	if strings.HasPrefix(q[i:], strings.Repeat(quote, 3)) {
+		quote = strings.Repeat(quote, 3)
+	}
+	for j := i + len(quote); j < len(q); j++ {
+		switch {
+		case q[j] == '\\':
+			j++
+		case strings.HasPrefix(q[j:], quote):
+			return j + len(quote), nil
+		case len(quote) == 1 && (q[j] == '\n' || q[j] == '\r'):
+			return 0, &SyntaxError{i, "unterminated string literal"}
+		}
+	}
+	return 0, &SyntaxError{i, "unterminated string literal"}
+}
+
+// numberEnd returns the end of the numeric literal starting at i.
+func numberEnd(q string, i int) int {
+	for i < len(q) && isDigit(q[i]) {
+		i++
+	}
+	if i+1 < len(q) && q[i] == '.' && isDigit(q[i+1]) {
+		for i++; i < len(q) && isDigit(q[i]); i++ {
+		}
+	}
+	if i < len(q) && (q[i] == 'e' || q[i] == 'E') {
+		j := i + 1
+		if j < len(q) && (q[j] == '+' || q[j] == '-') {
+			j++
+		}
+		if j < len(q) && isDigit(q[j]) {
+			for i = j; i < len(q) && isDigit(q[i]); i++ {
+			}
+		}
+	}
+	return i
+}
+
+// nameEnd returns the end of the (prefixed) name starting at i. Names may
+// contain dots, but not end with one.
+func nameEnd(q string, i int) int {
+	for i < len(q) && (isNameByte(q[i]) || q[i] == ':' || q[i] == '.' || q[i] == '-' || q[i] == '%') {
+		i++
+	}
+	for q[i-1] == '.' {
+		i--
+	}
+	return i
+}
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+func isAlnum(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || isDigit(c)
+}
+
+func isNameStart(c byte) bool { return isAlnum(c) && !isDigit(c) || c == '_' || c >= 0x80 }
+
+func isNameByte(c byte) bool { return isAlnum(c) || c == '_' || c >= 0x80 }
@@ -0,0 +1,66 @@
+package sparql
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// MaxConcurrentRequests limits the number of requests the Repo has in flight
+// at the same time to n, for stores with a small pool of worker threads.
+// Further requests wait for one to finish, or for the context of the Repo to
+// be done. A request is in flight until its response has been read, which
+// includes the time taken to decode results. Requests cancelling queries or
+// managing transactions are not limited.
+func MaxConcurrentRequests(n int) func(*Repo) error {
+	return func(r *Repo) error {
+		if n <= 0 {
+			return errors.New("sparql: maximum number of concurrent requests must be positive")
+		}
+		if err := r.repoScoped("MaxConcurrentRequests"); err != nil {
+			return err
+		}
+		r.sem = make(chan struct{}, n)
+		return nil
+	}
+}
+
+// control returns the Repo to send control requests through, such as to
+// cancel queries or manage transactions. They are not limited, as they would
+// otherwise wait for the very requests they are to cancel, or queue behind
+// unrelated ones while a transaction is held open.
+func (r *Repo) control() *Repo {
+	if r.sem == nil {
+		return r
+	}
+	c := r.copy()
+	c.sem = nil
+	return c
+}
+
+// acquire waits for a request to be allowed, and returns the function to
+// call once it is done.
+func (r *Repo) acquire() (release func(), err error) {
+	if r.sem == nil {
+		return func() {}, nil
+	}
+	select {
+	case r.sem <- struct{}{}:
+		var once sync.Once
+		return func() { once.Do(func() { <-r.sem }) }, nil
+	case <-r.ctx.Done():
+		return nil, r.ctx.Err()
+	}
+}
+
+// releasingBody releases the request it is the response body of when closed.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
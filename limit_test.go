@@ -0,0 +1,81 @@
+package sparql
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMaxConcurrentRequests(t *testing.T) {
+	var inFlight, peak int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		w.Write([]byte(testEmptyResults))
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL, "fuseki", MaxConcurrentRequests(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	derived, err := repo.With(QueryParam("x", "y"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(r *Repo) {
+			defer wg.Done()
+			if _, err := r.Query("SELECT * WHERE { ?s ?p ?o }"); err != nil {
+				t.Error(err)
+			}
+		}([]*Repo{repo, derived}[i%2])
+	}
+	wg.Wait()
+	if peak > 2 {
+		t.Errorf("Got %d concurrent requests; want at most 2", peak)
+	}
+
+	// Waiting for a slot ends with the context.
+	repo.sem <- struct{}{}
+	repo.sem <- struct{}{}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := repo.Query("SELECT * WHERE { ?s ?p ?o }", WithContext(ctx)); err != context.DeadlineExceeded {
+		t.Errorf("Got error %v; want context.DeadlineExceeded", err)
+	}
+
+	// Control requests do not wait for a slot.
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	c, err := repo.With(WithContext(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.call("POST", ts.URL, nil); err != nil {
+		t.Errorf("Got error %v for control request; want none", err)
+	}
+
+	for _, n := range []int{0, -1} {
+		if _, err := NewRepo("http://localhost/sparql", "fuseki", MaxConcurrentRequests(n)); err == nil {
+			t.Errorf("Got no error for MaxConcurrentRequests(%d)", n)
+		}
+	}
+	if _, err := repo.Query("SELECT * WHERE { ?s ?p ?o }", MaxConcurrentRequests(1)); err == nil {
+		t.Error("Got no error for MaxConcurrentRequests given to a single query")
+	}
+}
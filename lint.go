@@ -0,0 +1,306 @@
+package sparql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LintWarning describes a likely problem found in a query by Lint.
+type LintWarning struct {
+	Offset int    // byte offset in the query
+	Rule   string // identifies the kind of problem, e.g. "unbounded-select"
+	Msg    string
+}
+
+func (w LintWarning) String() string {
+	return fmt.Sprintf("offset %d: %s (%s)", w.Offset, w.Msg, w.Rule)
+}
+
+// Lint checks the query for common footguns, using heuristics which may
+// give false positives:
+//   - unbounded-select: a SELECT query without a LIMIT
+//   - cross-product: a group of patterns which share no variables, making
+//     the store compute their cross product
+//   - unused-projection: a projected variable not used in the WHERE clause,
+//     which is therefore never bound
+//   - unsatisfiable-filter: a FILTER which can never match, because it is
+//     false or uses a variable not bound by any pattern
+//
+// Queries which do not lex are not linted; see Validate.
+func Lint(q string) []LintWarning {
+	toks, err := lex(q)
+	if err != nil {
+		return nil
+	}
+	i := skipPrologue(toks)
+	if i == len(toks) {
+		return nil
+	}
+	form := toks[i]
+
+	start := whereStart(toks, i)
+	if start < 0 {
+		return nil
+	}
+	end := closing(toks, start)
+	where := toks[start : end+1]
+
+	var ws []LintWarning
+	if form.is("SELECT") {
+		ws = append(ws, lintProjection(toks[i+1:start], where)...)
+		if !hasTopLevel(toks[end+1:], "LIMIT") && !(hasAggregate(toks[i+1:start]) && !hasTopLevel(toks[end+1:], "GROUP")) {
+			ws = append(ws, LintWarning{form.pos, "unbounded-select", "SELECT without LIMIT may return unexpectedly many solutions"})
+		}
+	}
+	ws = append(ws, lintCrossProducts(where)...)
+	ws = append(ws, lintFilters(where)...)
+	return ws
+}
+
+// LintHook sets a function to be called with the warnings Lint finds in
+// every query, before it is sent. If the function returns an error, the
+// query is not sent and the error is returned instead.
+func LintHook(f func(q string, warnings []LintWarning) error) func(*Repo) error {
+	return func(r *Repo) error {
+		r.lintHook = f
+		return nil
+	}
+}
+
+// lint runs the lint hook of Repo on the query, if there is one.
+func (r *Repo) lint(q string) error {
+	if r.lintHook == nil {
+		return nil
+	}
+	if ws := Lint(q); len(ws) > 0 {
+		return r.lintHook(q, ws)
+	}
+	return nil
+}
+
+// whereStart returns the index of the opening brace of the WHERE clause,
+// searching from the query form at i, or -1 if there is none.
+func whereStart(toks []token, i int) int {
+	if toks[i].is("CONSTRUCT") && i+1 < len(toks) && toks[i+1].is("{") {
+		i = closing(toks, i+1)
+	}
+	for ; i < len(toks); i++ {
+		if toks[i].is("{") {
+			return i
+		}
+	}
+	return -1
+}
+
+// closing returns the index of the bracket closing the one at i, or the
+// last index if it is not closed.
+func closing(toks []token, i int) int {
+	depth := 0
+	for j := i; j < len(toks); j++ {
+		switch {
+		case toks[j].is("{") || toks[j].is("(") || toks[j].is("["):
+			depth++
+		case toks[j].is("}") || toks[j].is(")") || toks[j].is("]"):
+			if depth--; depth == 0 {
+				return j
+			}
+		}
+	}
+	return len(toks) - 1
+}
+
+// hasTopLevel reports whether the keyword occurs outside of brackets.
+func hasTopLevel(toks []token, keyword string) bool {
+	for j := 0; j < len(toks); j++ {
+		if toks[j].is("{") || toks[j].is("(") {
+			j = closing(toks, j)
+		} else if toks[j].is(keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+var aggregates = map[string]bool{
+	"COUNT": true, "SUM": true, "MIN": true, "MAX": true, "AVG": true, "SAMPLE": true, "GROUP_CONCAT": true,
+}
+
+// hasAggregate reports whether the projection uses an aggregate function.
+func hasAggregate(projection []token) bool {
+	for _, t := range projection {
+		if t.kind == tokWord && aggregates[strings.ToUpper(t.text)] {
+			return true
+		}
+	}
+	return false
+}
+
+// vars returns the set of variable names occurring in the tokens.
+func vars(toks []token) map[string]bool {
+	vs := make(map[string]bool)
+	for _, t := range toks {
+		if t.kind == tokVar {
+			vs[t.text[1:]] = true
+		}
+	}
+	return vs
+}
+
+// lintProjection warns about projected variables not used in the WHERE clause.
+func lintProjection(projection, where []token) []LintWarning {
+	used := vars(where)
+	var ws []LintWarning
+	for j := 0; j < len(projection); j++ {
+		t := projection[j]
+		switch {
+		case t.is("("):
+			// (expression AS ?var) binds ?var itself.
+			j = closing(projection, j)
+		case t.kind == tokVar && !used[t.text[1:]]:
+			ws = append(ws, LintWarning{t.pos, "unused-projection",
+				fmt.Sprintf("projected variable %s is not used in the WHERE clause", t.text)})
+		case t.is("WHERE") || t.is("FROM"):
+			return ws
+		}
+	}
+	return ws
+}
+
+// lintCrossProducts warns about groups of patterns which share no variables.
+// The group is given including its braces, and nested groups are checked too.
+func lintCrossProducts(group []token) []LintWarning {
+	// An unclosed group is a syntax error, which lint leaves to the store.
+	if len(group) < 2 || !group[len(group)-1].is("}") {
+		return nil
+	}
+	var ws []LintWarning
+
+	// Split the group into statements, which are joined through their
+	// shared variables. Filters do not bind variables, so are left out.
+	var stmts []map[string]bool
+	var stmt []token
+	flush := func() {
+		if len(stmt) > 0 && !stmt[0].is("FILTER") {
+			if vs := vars(stmt); len(vs) > 0 {
+				stmts = append(stmts, vs)
+			}
+		}
+		stmt = nil
+	}
+	body := group[1 : len(group)-1]
+	for j := 0; j < len(body); j++ {
+		t := body[j]
+		switch {
+		case t.is("{"):
+			k := closing(body, j)
+			if !(len(stmt) > 0 && stmt[len(stmt)-1].is("VALUES")) {
+				ws = append(ws, lintCrossProducts(body[j:k+1])...)
+			}
+			stmt = append(stmt, body[j:k+1]...)
+			j = k
+			if k+1 < len(body) && !body[k+1].is("UNION") && !body[k+1].is(".") {
+				flush()
+			}
+		case t.is("(") || t.is("["):
+			k := closing(body, j)
+			stmt = append(stmt, body[j:k+1]...)
+			j = k
+			if t.is("(") && (stmt[0].is("FILTER") || stmt[0].is("BIND")) {
+				flush()
+			}
+		case t.is("."):
+			flush()
+		case t.is("FILTER") || t.is("BIND") || t.is("OPTIONAL") || t.is("MINUS") || t.is("VALUES"):
+			flush()
+			stmt = append(stmt, t)
+		default:
+			stmt = append(stmt, t)
+		}
+	}
+	flush()
+
+	// Count the connected components of statements.
+	components := 0
+	seen := make([]bool, len(stmts))
+	for s := range stmts {
+		if seen[s] {
+			continue
+		}
+		components++
+		queue := []int{s}
+		seen[s] = true
+		for len(queue) > 0 {
+			cur := queue[0]
+			queue = queue[1:]
+			for o := range stmts {
+				if !seen[o] && shareVar(stmts[cur], stmts[o]) {
+					seen[o] = true
+					queue = append(queue, o)
+				}
+			}
+		}
+	}
+	if components > 1 {
+		ws = append(ws, LintWarning{group[0].pos, "cross-product",
+			fmt.Sprintf("group has %d sets of patterns sharing no variables, which are joined as a cross product", components)})
+	}
+	return ws
+}
+
+func shareVar(a, b map[string]bool) bool {
+	for v := range a {
+		if b[v] {
+			return true
+		}
+	}
+	return false
+}
+
+// lintFilters warns about filters which can never match.
+func lintFilters(where []token) []LintWarning {
+	// Collect the variables used outside of filters.
+	bound := make(map[string]bool)
+	var filters [][]token
+	for j := 0; j < len(where); j++ {
+		t := where[j]
+		if !t.is("FILTER") || j+1 == len(where) {
+			if t.kind == tokVar {
+				bound[t.text[1:]] = true
+			}
+			continue
+		}
+		k := j + 1
+		if where[k].is("NOT") || where[k].is("EXISTS") {
+			// Patterns in EXISTS bind variables like any other.
+			continue
+		}
+		if where[k].kind == tokWord && k+1 < len(where) {
+			k++ // function call, e.g. FILTER regex(...)
+		}
+		end := closing(where, k)
+		filters = append(filters, where[j:end+1])
+		j = end
+	}
+
+	var ws []LintWarning
+	for _, f := range filters {
+		body := f[1:]
+		if len(body) == 3 && body[0].is("(") && (body[1].is("false") || body[1].text == "0") {
+			ws = append(ws, LintWarning{f[0].pos, "unsatisfiable-filter", "FILTER is always false"})
+			continue
+		}
+		for j := 0; j < len(body); j++ {
+			t := body[j]
+			if t.is("EXISTS") {
+				j = closing(body, j+1)
+				continue
+			}
+			if t.kind == tokVar && !bound[t.text[1:]] {
+				ws = append(ws, LintWarning{t.pos, "unsatisfiable-filter",
+					fmt.Sprintf("FILTER uses %s, which is not bound by any pattern", t.text)})
+				break
+			}
+		}
+	}
+	return ws
+}
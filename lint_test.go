@@ -0,0 +1,71 @@
+package sparql
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		q     string
+		rules []string
+	}{
+		{"SELECT ?s WHERE { ?s ?p ?o } LIMIT 10", nil},
+		{"SELECT ?s WHERE { ?s ?p ?o }", []string{"unbounded-select"}},
+		{"SELECT (COUNT(*) AS ?n) WHERE { ?s ?p ?o }", nil},
+		{"SELECT ?s ?n WHERE { ?s ?p ?o } LIMIT 1", []string{"unused-projection"}},
+		{"SELECT * WHERE { ?s ?p ?o . ?a ?b ?c } LIMIT 1", []string{"cross-product"}},
+		{"SELECT * WHERE { ?s ?p ?o . ?o ?b ?c } LIMIT 1", nil},
+		{"SELECT * WHERE { ?s ?p ?o OPTIONAL { ?o ?b ?c } } LIMIT 1", nil},
+		{"SELECT * WHERE { { ?s ?p ?o } UNION { ?s ?q ?o } . ?s a ?t } LIMIT 1", nil},
+		{"SELECT * WHERE { ?s ?p ?o FILTER(?x > 1) } LIMIT 1", []string{"unsatisfiable-filter"}},
+		{"SELECT * WHERE { ?s ?p ?o FILTER regex(?o, \"x\") } LIMIT 1", nil},
+		{"SELECT * WHERE { ?s ?p ?o FILTER NOT EXISTS { ?s a ?t } } LIMIT 1", nil},
+		{"SELECT * WHERE { ?s ?p ?o FILTER(false) } LIMIT 1", []string{"unsatisfiable-filter"}},
+		{"SELECT * WHERE { ?s ?p ?o BIND(STR(?o) AS ?str) FILTER(?str != \"\") } LIMIT 1", nil},
+		{"ASK { ?s ?p ?o . ?a ?b ?c }", []string{"cross-product"}},
+		{"SELECT * WHERE {", []string{"unbounded-select"}},
+		{"ASK {", nil},
+		{"ASK { ?s ?p ?o { ?a ?b ?c", nil},
+	}
+
+	for _, test := range tests {
+		ws := Lint(test.q)
+		if len(ws) != len(test.rules) {
+			t.Errorf("Lint(%q) = %v; want rules %v", test.q, ws, test.rules)
+			continue
+		}
+		for i, w := range ws {
+			if w.Rule != test.rules[i] {
+				t.Errorf("Lint(%q) = %v; want rules %v", test.q, ws, test.rules)
+			}
+		}
+	}
+}
+
+func TestLintHook(t *testing.T) {
+	var sent bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		sent = true
+		w.Write([]byte(testEmptyResults))
+	}))
+	defer ts.Close()
+
+	errLint := errors.New("lint")
+	repo, err := NewRepo(ts.URL, "ontotext", LintHook(func(q string, ws []LintWarning) error {
+		return errLint
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = repo.Query("SELECT ?s WHERE { ?s ?p ?o } LIMIT 1"); err != nil || !sent {
+		t.Errorf("Query() without warnings returned %v, sent: %v; want it sent", err, sent)
+	}
+	sent = false
+	if _, err = repo.Query("SELECT ?s WHERE { ?s ?p ?o }"); err != errLint || sent {
+		t.Errorf("Query() rejected by lint hook returned %v, sent: %v; want errLint", err, sent)
+	}
+}
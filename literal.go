@@ -0,0 +1,256 @@
+package sparql
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/knakk/rdf"
+)
+
+// TermMarshaler is implemented by types which can represent themselves as an
+// RDF term, for use in queries with FormatLiteral, Literal and Bind.
+type TermMarshaler interface {
+	MarshalTerm() (rdf.Term, error)
+}
+
+// FormatLiteral returns the value in SPARQL syntax, with any characters
+// escaped as needed:
+//   - strings as xsd:string literals
+//   - booleans as xsd:boolean, and integers as xsd:integer literals
+//   - floating point numbers as xsd:double literals
+//   - *big.Rat and Decimal values as xsd:decimal literals
+//   - time.Time values as xsd:dateTime literals, keeping the offset of
+//     their location; see TimeFormat for other formats
+//   - byte slices as xsd:base64Binary literals
+//   - rdf.Term values, including IRIs and blank nodes, and the terms returned
+//     by TermMarshaler implementations, as they are
+//
+// It returns an error for values of other types, for IRIs and datatypes
+// which are not valid according to ValidateIRI, and for malformed language
+// tags.
+func FormatLiteral(v interface{}) (string, error) {
+	return formatLiteral(v, defaultTimeFormat)
+}
+
+// formatLiteral implements FormatLiteral, formatting times in tf.
+func formatLiteral(v interface{}, tf TimeFormat) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return quoteString(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v), nil
+	case float32:
+		return formatDouble(float64(v)), nil
+	case float64:
+		return formatDouble(v), nil
+	case *big.Rat:
+		return formatDecimal(v)
+	case Decimal:
+		if !decimalSyntax.MatchString(string(v)) {
+			return "", fmt.Errorf("sparql: invalid decimal %q", string(v))
+		}
+		// SPARQL reads "5." as the integer 5 ending a triple, so the point
+		// must be followed by a digit.
+		if !strings.Contains(string(v), ".") {
+			v += ".0"
+		} else if strings.HasSuffix(string(v), ".") {
+			v += "0"
+		}
+		return strings.TrimPrefix(string(v), "+"), nil
+	case time.Time:
+		return tf.Literal(v).text, nil
+	case []byte:
+		return TypedLiteral(base64.StdEncoding.EncodeToString(v), xsdNS+"base64Binary").text, nil
+	case TermMarshaler:
+		t, err := v.MarshalTerm()
+		if err != nil {
+			return "", err
+		}
+		return formatLiteral(t, tf)
+	case rdf.IRI:
+		if err := ValidateIRI(v.String()); err != nil {
+			return "", err
+		}
+		return "<" + v.String() + ">", nil
+	case rdf.Literal:
+		if v.Lang() != "" {
+			if langTag.FindString(v.Lang()) != v.Lang() {
+				return "", fmt.Errorf("sparql: invalid language tag %q", v.Lang())
+			}
+			return LangLiteral(v.String(), v.Lang()).text, nil
+		}
+		if dt := v.DataType.String(); dt != "" {
+			if err := ValidateIRI(dt); err != nil {
+				return "", err
+			}
+		}
+		return TypedLiteral(v.String(), v.DataType.String()).text, nil
+	case rdf.Term:
+		return v.Serialize(rdf.NTriples), nil
+	}
+	return "", fmt.Errorf("sparql: cannot format %T as a literal", v)
+}
+
+// TypedLiteral returns the literal with the given lexical form and datatype
+// IRI, or a plain string literal if datatype is empty, e.g.
+//
+//	TypedLiteral("2020-01-02", "http://www.w3.org/2001/XMLSchema#date")
+//
+// Characters which may not appear in IRIs are percent-encoded in datatype.
+func TypedLiteral(value, datatype string) Expr {
+	if datatype == "" || datatype == xsdNS+"string" {
+		return Expr{quoteString(value), precOperand}
+	}
+	return Expr{quoteString(value) + "^^<" + escapeIRI(datatype) + ">", precOperand}
+}
+
+// langTag matches the language tags of literals, as defined by SPARQL.
+var langTag = regexp.MustCompile(`^[a-zA-Z]+(-[a-zA-Z0-9]+)*`)
+
+// LangLiteral returns the string literal with the given language tag. The
+// tag is cut short at the first character it may not contain, and a plain
+// string literal is returned if nothing valid is left.
+func LangLiteral(value, lang string) Expr {
+	if lang = langTag.FindString(lang); lang == "" {
+		return Expr{quoteString(value), precOperand}
+	}
+	return Expr{quoteString(value) + "@" + lang, precOperand}
+}
+
+// endsCondition reports whether the token ends the conditions of a GROUP BY
+// or ORDER BY clause.
+func endsCondition(t token) bool {
+	for _, s := range []string{"}", "GROUP", "HAVING", "ORDER", "LIMIT", "OFFSET", "VALUES"} {
+		if t.is(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatDouble(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return `"NaN"^^<` + xsdNS + `double>`
+	case math.IsInf(f, 1):
+		return `"INF"^^<` + xsdNS + `double>`
+	case math.IsInf(f, -1):
+		return `"-INF"^^<` + xsdNS + `double>`
+	}
+	return strconv.FormatFloat(f, 'e', -1, 64)
+}
+
+// Bind substitutes the variables of the query with the given values, keyed
+// by variable name without the leading ?. Values are formatted with
+// FormatLiteral, or written as they are if they are an Expr. Variables in the
+// projection of a SELECT query are replaced by (value AS ?name), so that the
+// solutions still bind them, and those in GROUP BY and ORDER BY by (value),
+// as a bare value is not allowed there. Variables projected by subqueries
+// cannot be bound, as the subquery would bind them for the enclosing query
+// as well. Occurrences in strings, IRIs and comments are left alone, as the
+// query is tokenized first.
+func Bind(q string, values map[string]interface{}) (string, error) {
+	return bind(q, values, defaultTimeFormat)
+}
+
+// Bind substitutes the variables of the query with the given values, like
+// the Bind function, formatting times as set by TimeLiterals.
+func (r *Repo) Bind(q string, values map[string]interface{}) (string, error) {
+	return bind(q, values, r.timeFormat)
+}
+
+// bind implements Bind, formatting times in tf.
+func bind(q string, values map[string]interface{}, tf TimeFormat) (string, error) {
+	toks, err := lex(q)
+	if err != nil {
+		return "", err
+	}
+	terms := make(map[string]string, len(values))
+	for name, v := range values {
+		name = strings.TrimLeft(name, "?$")
+		if e, ok := v.(Expr); ok {
+			terms[name] = e.text
+			continue
+		}
+		if terms[name], err = formatLiteral(v, tf); err != nil {
+			return "", fmt.Errorf("sparql: binding ?%s: %v", name, err)
+		}
+	}
+
+	// depths holds the nesting of parentheses at each token.
+	depths := make([]int, len(toks))
+	depth := 0
+	for i, t := range toks {
+		if t.is(")") {
+			depth--
+		}
+		depths[i] = depth
+		if t.is("(") {
+			depth++
+		}
+	}
+
+	// A projection is found between SELECT and its WHERE clause, and the
+	// conditions of GROUP BY and ORDER BY end with the next solution modifier
+	// or the end of the subquery.
+	const (
+		inProjection = 1 + iota
+		inSubquery
+		inCondition
+	)
+	positions := make([]int, len(toks))
+	top := skipPrologue(toks)
+	for i, t := range toks {
+		switch {
+		case t.is("SELECT"):
+			pos := inProjection
+			if i != top {
+				pos = inSubquery
+			}
+			for j := i + 1; j < len(toks) && !toks[j].is("{") && !toks[j].is("WHERE"); j++ {
+				if depths[j] == depths[i] {
+					positions[j] = pos
+				}
+			}
+		case (t.is("GROUP") || t.is("ORDER")) && i+1 < len(toks) && toks[i+1].is("BY"):
+			for j := i + 2; j < len(toks) && depths[j] >= depths[i] && !endsCondition(toks[j]); j++ {
+				if depths[j] == depths[i] {
+					positions[j] = inCondition
+				}
+			}
+		}
+	}
+
+	var b strings.Builder
+	last := 0
+	for i, t := range toks {
+		term, ok := terms[strings.TrimLeft(t.text, "?$")]
+		if t.kind != tokVar || !ok {
+			continue
+		}
+		b.WriteString(q[last:t.pos])
+		switch positions[i] {
+		case inProjection:
+			b.WriteString("(" + term + " AS ?" + t.text[1:] + ")")
+		case inSubquery:
+			return "", fmt.Errorf("sparql: cannot bind ?%s, which a subquery projects", t.text[1:])
+		case inCondition:
+			b.WriteString("(" + term + ")")
+		default:
+			b.WriteString(term)
+		}
+		last = t.pos + len(t.text)
+	}
+	b.WriteString(q[last:])
+	return b.String(), nil
+}
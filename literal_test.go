@@ -0,0 +1,106 @@
+package sparql
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/knakk/rdf"
+)
+
+type point struct{ x, y int }
+
+func (p point) MarshalTerm() (rdf.Term, error) {
+	dt, err := rdf.NewIRI("http://example.org/point")
+	return rdf.NewTypedLiteral(strings.Repeat("x", p.x)+"/"+strings.Repeat("y", p.y), dt), err
+}
+
+func TestFormatLiteral(t *testing.T) {
+	iri, _ := rdf.NewIRI("http://example.org/a")
+	lang, _ := rdf.NewLangLiteral("chat", "fr")
+	tests := []struct {
+		v    interface{}
+		want string
+	}{
+		{"say \"hi\"\n", `"say \"hi\"\n"`},
+		{true, "true"},
+		{-42, "-42"},
+		{uint8(7), "7"},
+		{0.25, "2.5e-01"},
+		{time.Date(2020, 1, 2, 3, 4, 5, 6000000, time.FixedZone("", 3600)), `"2020-01-02T03:04:05.006+01:00"^^<http://www.w3.org/2001/XMLSchema#dateTime>`},
+		{[]byte("hi"), `"aGk="^^<http://www.w3.org/2001/XMLSchema#base64Binary>`},
+		{iri, "<http://example.org/a>"},
+		{lang, `"chat"@fr`},
+		{point{1, 2}, `"x/yy"^^<http://example.org/point>`},
+	}
+	for _, test := range tests {
+		got, err := FormatLiteral(test.v)
+		if err != nil {
+			t.Errorf("Got error formatting %v: %v", test.v, err)
+		} else if got != test.want {
+			t.Errorf("Got %s formatting %v; want %s", got, test.v, test.want)
+		}
+	}
+
+	if _, err := FormatLiteral(struct{}{}); err == nil {
+		t.Error("Got no error formatting a struct")
+	}
+	dt, _ := rdf.NewIRI("http://example.org/%zz")
+	if _, err := FormatLiteral(rdf.NewTypedLiteral("x", dt)); err == nil {
+		t.Error("Got no error formatting a literal with invalid datatype")
+	}
+}
+
+func TestLiteralEscaping(t *testing.T) {
+	tests := []struct {
+		e    Expr
+		want string
+	}{
+		{TypedLiteral("1", "x> } ; DROP ALL ; <y"), `"1"^^<x%3E%20%7D%20;%20DROP%20ALL%20;%20%3Cy>`},
+		{LangLiteral("chat", "fr"), `"chat"@fr`},
+		{LangLiteral("color", "en-US } ; DROP ALL"), `"color"@en-US`},
+		{LangLiteral("chat", "} ; DROP ALL"), `"chat"`},
+	}
+	for _, test := range tests {
+		if got := test.e.String(); got != test.want {
+			t.Errorf("Got %s; want %s", got, test.want)
+		}
+	}
+}
+
+func TestBind(t *testing.T) {
+	iri, _ := rdf.NewIRI("http://example.org/a")
+	q := `SELECT ?s ?name (COUNT(?o) AS ?n) WHERE { ?s <p> ?o ; <name> ?name . FILTER(?min < ?o) } # ?name`
+	got, err := Bind(q, map[string]interface{}{
+		"s":    iri,
+		"?o":   IRI("http://example.org/o"),
+		"name": `Ann "A" Smith`,
+		"min":  3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `SELECT (<http://example.org/a> AS ?s) ("Ann \"A\" Smith" AS ?name) (COUNT(<http://example.org/o>) AS ?n) WHERE { <http://example.org/a> <p> <http://example.org/o> ; <name> "Ann \"A\" Smith" . FILTER(3 < <http://example.org/o>) } # ?name`
+	if got != want {
+		t.Errorf("Got query:\n%s\nwant:\n%s", got, want)
+	}
+
+	// GROUP BY and ORDER BY take the values as expressions, also within
+	// subqueries, but variables projected by subqueries cannot be bound.
+	q = `SELECT ?s (MAX(?n) AS ?m) WHERE { { SELECT ?o WHERE { ?s <p> ?o } ORDER BY ?s DESC(?o) LIMIT 10 } ?o <n> ?n } GROUP BY ?s (?o AS ?x) HAVING (COUNT(?s) > 1)`
+	got, err = Bind(q, map[string]interface{}{"s": iri})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = `SELECT (<http://example.org/a> AS ?s) (MAX(?n) AS ?m) WHERE { { SELECT ?o WHERE { <http://example.org/a> <p> ?o } ORDER BY (<http://example.org/a>) DESC(?o) LIMIT 10 } ?o <n> ?n } GROUP BY (<http://example.org/a>) (?o AS ?x) HAVING (COUNT(<http://example.org/a>) > 1)`
+	if got != want {
+		t.Errorf("Got query:\n%s\nwant:\n%s", got, want)
+	}
+	if _, err := Bind(q, map[string]interface{}{"o": iri}); err == nil {
+		t.Error("Got no error binding a variable projected by a subquery")
+	}
+
+	if _, err := Bind(q, map[string]interface{}{"s": struct{}{}}); err == nil {
+		t.Error("Got no error binding unsupported value")
+	}
+}
@@ -0,0 +1,151 @@
+package sparql
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"time"
+)
+
+// Multipart makes Repo send updates and uploads as multipart/form-data, with
+// the update or data as a file part, for stores and gateways which require
+// it.
+func Multipart() func(*Repo) error {
+	return func(r *Repo) error {
+		r.multipart = true
+		return nil
+	}
+}
+
+// fileFields holds the form fields sent as file parts, and their media types.
+var fileFields = map[string]string{
+	"update":  "application/sparql-update",
+	"request": "application/sparql-update",
+}
+
+// multipartForm encodes the form as multipart/form-data, returning the body and
+// its content type.
+func multipartForm(form url.Values) (*bytes.Buffer, string, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for k, vs := range form {
+		for _, v := range vs {
+			var err error
+			if mediaType, ok := fileFields[k]; ok {
+				err = writeFilePart(w, k, k+".rq", mediaType, bytes.NewBufferString(v))
+			} else {
+				err = w.WriteField(k, v)
+			}
+			if err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return &buf, w.FormDataContentType(), nil
+}
+
+func writeFilePart(w *multipart.Writer, field, filename, mediaType string, data io.Reader) error {
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q; filename=%q`, field, filename))
+	h.Set("Content-Type", mediaType)
+	part, err := w.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(part, data)
+	return err
+}
+
+// Upload adds the RDF data, serialized in the given format (a media type such
+// as text/turtle), to the named graph, or to the default graph if graph is
+// empty. Any options given apply to this upload only.
+func (r *Repo) Upload(graph string, data io.Reader, format string, options ...func(*Repo) error) error {
+	if r.closed() {
+		return ErrClosed
+	}
+	r, err := r.derive(options...)
+	if err != nil {
+		return err
+	}
+
+	// Uploads are recorded in the statistics by the format of the data.
+	start := time.Now()
+	err = r.upload(graph, data, format)
+	r.stats.record("UPLOAD <"+format+">", time.Since(start), err)
+	return err
+}
+
+// upload implements Upload, once options are applied.
+func (r *Repo) upload(graph string, data io.Reader, format string) error {
+	if r.readOnly {
+		return ErrReadOnly
+	}
+	if r.dryRun {
+		return nil
+	}
+	if !knownDBType(r.dbType) || r.dbType == "oracle" {
+		return ErrNotSupported
+	}
+
+	addr := graphStoreURL(r.updateURL(), "graph", graph)
+	if f := dialects[r.dbType].graphStore; f != nil {
+		addr = f(r, graph)
+	}
+
+	contentType := format
+	if r.multipart {
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		if err := writeFilePart(w, "file", "data", format, data); err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+		data, contentType = &buf, w.FormDataContentType()
+	}
+
+	req, err := http.NewRequest("POST", addr, data)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	defer r.asks.clear()
+
+	p := r.startProgress()
+	resp, err := r.do(req, false)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(p.track(resp.Body))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("sparql: upload failed: %s: %s", resp.Status, bytes.TrimSpace(b))
+	}
+	p.done(0)
+	return nil
+}
+
+// graphStoreURL returns the address of the graph at a graph store, using the
+// named parameter to identify it.
+func graphStoreURL(addr, param, graph string) string {
+	if graph == "" {
+		if param == "graph" {
+			return addr + "?default"
+		}
+		return addr
+	}
+	return addr + "?" + url.Values{param: {graph}}.Encode()
+}
@@ -0,0 +1,78 @@
+package sparql
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMultipartUpdate(t *testing.T) {
+	var update, mediaType, param string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		f, h, err := req.FormFile("update")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		b, _ := ioutil.ReadAll(f)
+		update, mediaType, param = string(b), h.Header.Get("Content-Type"), req.FormValue("timeout")
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL+"/ds/query", "fuseki", Multipart(), QueryParam("timeout", "5"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := "INSERT DATA { <a> <p> 1 }"
+	if _, err := repo.Update(q); err != nil {
+		t.Fatal(err)
+	}
+	if update != q || mediaType != "application/sparql-update" || param != "5" {
+		t.Errorf("Got update %q of type %q with timeout %q", update, mediaType, param)
+	}
+}
+
+func TestUpload(t *testing.T) {
+	var got, path, graph, mediaType string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		path, graph = req.URL.Path, req.URL.Query().Get("graph")
+		if strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/form-data") {
+			f, h, err := req.FormFile("file")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			b, _ := ioutil.ReadAll(f)
+			got, mediaType = string(b), h.Header.Get("Content-Type")
+			return
+		}
+		b, _ := ioutil.ReadAll(req.Body)
+		got, mediaType = string(b), req.Header.Get("Content-Type")
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL+"/ds/sparql", "fuseki")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := "<a> <p> 1 ."
+	for _, options := range [][]func(*Repo) error{nil, {Multipart()}} {
+		got, mediaType = "", ""
+		if err := repo.Upload("http://example.org/g", strings.NewReader(data), "text/turtle", options...); err != nil {
+			t.Fatal(err)
+		}
+		if got != data || mediaType != "text/turtle" || path != "/ds/data" || graph != "http://example.org/g" {
+			t.Errorf("Got %q of type %q uploaded to %s, graph %q", got, mediaType, path, graph)
+		}
+	}
+
+	ro, err := NewRepo(ts.URL+"/ds/sparql", "fuseki", ReadOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ro.Upload("", strings.NewReader(data), "text/turtle"); err != ErrReadOnly {
+		t.Errorf("Got error %v; want ErrReadOnly", err)
+	}
+}
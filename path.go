@@ -0,0 +1,114 @@
+package sparql
+
+import "strings"
+
+// Path is a SPARQL property path, for use as the predicate of triple
+// patterns. A plain IRI, prefixed name or "a" is a path by itself; more
+// complex paths are built with the functions below, which add parentheses
+// where the precedence of the operators requires them:
+//
+//	Seq(OneOrMore("foaf:knows"), "foaf:name")          // foaf:knows+/foaf:name
+//	OneOrMore(Alt("ex:parent", Inverse("ex:child")))   // (ex:parent|^ex:child)+
+//	Inverse(Seq("ex:a", "ex:b"))                       // ^(ex:a/ex:b)
+type Path string
+
+// Path precedence levels, from loosest to tightest binding.
+const (
+	precAlt = iota
+	precSeq
+	precInverse
+	precMod
+	precPrimary
+)
+
+// Seq returns the sequence path p1/p2/..., matching p1 followed by p2 and so on.
+func Seq(paths ...Path) Path {
+	return join(paths, "/", precSeq)
+}
+
+// Alt returns the alternative path p1|p2|..., matching any of the paths.
+func Alt(paths ...Path) Path {
+	return join(paths, "|", precAlt)
+}
+
+// Inverse returns the inverse path ^p, matching p from object to subject.
+func Inverse(p Path) Path {
+	return "^" + wrap(p, precMod)
+}
+
+// ZeroOrMore returns the path p*, matching p repeated any number of times.
+func ZeroOrMore(p Path) Path {
+	return wrap(p, precPrimary) + "*"
+}
+
+// OneOrMore returns the path p+, matching p repeated at least once.
+func OneOrMore(p Path) Path {
+	return wrap(p, precPrimary) + "+"
+}
+
+// ZeroOrOne returns the path p?, matching p at most once.
+func ZeroOrOne(p Path) Path {
+	return wrap(p, precPrimary) + "?"
+}
+
+// Negated returns the negated property set !(p1|p2|...), matching any
+// predicate but the given ones. The paths must be IRIs, or inverses of IRIs.
+func Negated(paths ...Path) Path {
+	if len(paths) == 1 {
+		return "!" + paths[0]
+	}
+	return "!(" + join(paths, "|", precAlt) + ")"
+}
+
+func join(paths []Path, op string, prec int) Path {
+	parts := make([]string, len(paths))
+	for i, p := range paths {
+		parts[i] = string(wrap(p, prec))
+	}
+	return Path(strings.Join(parts, op))
+}
+
+// wrap parenthesizes the path if it binds looser than prec.
+func wrap(p Path, prec int) Path {
+	if precedence(p) < prec {
+		return "(" + p + ")"
+	}
+	return p
+}
+
+// precedence returns the precedence level of the outermost operator of the path.
+func precedence(p Path) int {
+	s := strings.TrimSpace(string(p))
+	prec := precPrimary
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '<':
+			if j := strings.IndexByte(s[i:], '>'); j > 0 {
+				i += j
+			}
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case '|':
+			if depth == 0 {
+				return precAlt
+			}
+		case '/':
+			if depth == 0 {
+				prec = precSeq
+			}
+		}
+	}
+	if prec == precSeq {
+		return precSeq
+	}
+	switch {
+	case strings.HasPrefix(s, "^"):
+		return precInverse
+	case strings.HasSuffix(s, "*") || strings.HasSuffix(s, "+") || strings.HasSuffix(s, "?"):
+		return precMod
+	}
+	return precPrimary
+}
@@ -0,0 +1,30 @@
+package sparql
+
+import "testing"
+
+func TestPath(t *testing.T) {
+	tests := []struct {
+		got  Path
+		want string
+	}{
+		{Seq("foaf:knows", "foaf:name"), "foaf:knows/foaf:name"},
+		{Seq(OneOrMore("foaf:knows"), "foaf:name"), "foaf:knows+/foaf:name"},
+		{Alt("ex:a", Seq("ex:b", "ex:c")), "ex:a|ex:b/ex:c"},
+		{Seq(Alt("ex:a", "ex:b"), "ex:c"), "(ex:a|ex:b)/ex:c"},
+		{OneOrMore(Alt("ex:parent", Inverse("ex:child"))), "(ex:parent|^ex:child)+"},
+		{Inverse(Seq("ex:a", "ex:b")), "^(ex:a/ex:b)"},
+		{Inverse(ZeroOrMore("ex:a")), "^ex:a*"},
+		{ZeroOrMore(Inverse("ex:a")), "(^ex:a)*"},
+		{ZeroOrOne(OneOrMore("ex:a")), "(ex:a+)?"},
+		{Inverse(Inverse("ex:a")), "^(^ex:a)"},
+		{Seq("<http://example.org/a/b>", "a"), "<http://example.org/a/b>/a"},
+		{OneOrMore("<http://example.org/a|b>"), "<http://example.org/a|b>+"},
+		{Negated("rdf:type", Inverse("ex:p")), "!(rdf:type|^ex:p)"},
+		{Seq(Negated("rdf:type"), "ex:p"), "!rdf:type/ex:p"},
+	}
+	for _, test := range tests {
+		if string(test.got) != test.want {
+			t.Errorf("Got path %s; want %s", test.got, test.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package sparql
+
+import (
+	"sort"
+	"strings"
+)
+
+// WithPrefix registers a prefix for use in queries and updates: where a
+// query uses the prefix without declaring it, the declaration is added
+// before the query is sent. Given to NewRepo, it applies to all queries of
+// the Repo; given to a single query, it adds to or overrides the prefixes
+// of the Repo for that query only. Prefixes declared by the query itself
+// always take precedence.
+func WithPrefix(name, iri string) func(*Repo) error {
+	return func(r *Repo) error {
+		if r.prefixes == nil {
+			r.prefixes = make(map[string]string)
+		}
+		r.prefixes[strings.TrimSuffix(name, ":")] = iri
+		return nil
+	}
+}
+
+// addPrefixes declares the registered prefixes the query uses but does not
+// declare itself. Queries which do not lex are returned unchanged.
+func (r *Repo) addPrefixes(q string) string {
+	if len(r.prefixes) == 0 {
+		return q
+	}
+	toks, err := lex(q)
+	if err != nil {
+		return q
+	}
+	declared := declaredPrefixes(toks)
+	used := make(map[string]bool)
+	for _, t := range toks {
+		if t.kind != tokPName {
+			continue
+		}
+		name := t.text[:strings.IndexByte(t.text, ':')]
+		if _, ok := r.prefixes[name]; ok && !declared[name] {
+			used[name] = true
+		}
+	}
+	if len(used) == 0 {
+		return q
+	}
+
+	names := make([]string, 0, len(used))
+	for name := range used {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString("PREFIX " + name + ": <" + r.prefixes[name] + ">\n")
+	}
+	return b.String() + q
+}
@@ -0,0 +1,55 @@
+package sparql
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithPrefix(t *testing.T) {
+	var got string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got = req.FormValue("query")
+		w.Write([]byte(testEmptyResults))
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL, "fuseki",
+		WithPrefix("ex", "http://example.org/"),
+		WithPrefix("foaf:", "http://xmlns.com/foaf/0.1/"),
+		WithPrefix("unused", "http://example.org/unused#"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		q       string
+		options []func(*Repo) error
+		want    string
+	}{
+		{"SELECT * WHERE { ?s a foaf:Person ; ex:p ?o }", nil,
+			"PREFIX ex: <http://example.org/>\nPREFIX foaf: <http://xmlns.com/foaf/0.1/>\nSELECT * WHERE { ?s a foaf:Person ; ex:p ?o }"},
+		{"SELECT * WHERE { ?s ex:p ?o }", []func(*Repo) error{WithPrefix("ex", "http://example.com/other#")},
+			"PREFIX ex: <http://example.com/other#>\nSELECT * WHERE { ?s ex:p ?o }"},
+		{"PREFIX ex: <urn:ex:>\nSELECT * WHERE { ?s ex:p \"ex:not-a-name\" }", nil,
+			"PREFIX ex: <urn:ex:>\nSELECT * WHERE { ?s ex:p \"ex:not-a-name\" }"},
+		{"SELECT * WHERE { ?s <http://example.org/p> ?o }", nil,
+			"SELECT * WHERE { ?s <http://example.org/p> ?o }"},
+	}
+	for _, test := range tests {
+		if _, err := repo.Query(test.q, test.options...); err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("Got query:\n%s\nwant:\n%s", got, test.want)
+		}
+	}
+
+	// The per-query override does not stick.
+	if _, err := repo.Query("SELECT * WHERE { ?s ex:p ?o }"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "PREFIX ex: <http://example.org/>\nSELECT * WHERE { ?s ex:p ?o }"; got != want {
+		t.Errorf("Got query:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,99 @@
+package sparql
+
+import (
+	"io"
+	"time"
+)
+
+// Progress describes how far the retrieval of a response has come.
+type Progress struct {
+	// Bytes is the number of bytes of the response received so far.
+	Bytes int64
+
+	// Rows is the number of solutions (for queries) or triples (for
+	// construct queries) decoded so far. It is only known once the response
+	// has been fully received, unless results are in the JSON format.
+	Rows int
+
+	// Elapsed is the time since the request was sent.
+	Elapsed time.Duration
+
+	// Done is true for the report made once the response is fully received.
+	Done bool
+}
+
+// ReportProgress makes Repo call f periodically, at most once per interval,
+// while receiving the response to a query, and once more when done. This
+// allows long running queries and graph exports to report their progress.
+func ReportProgress(interval time.Duration, f func(Progress)) func(*Repo) error {
+	return func(r *Repo) error {
+		r.progressInterval, r.progressFunc = interval, f
+		return nil
+	}
+}
+
+// progress tracks the retrieval of a response, reporting it to the progress
+// function of Repo if there is one.
+type progress struct {
+	r        io.Reader
+	f        func(Progress)
+	interval time.Duration
+	start    time.Time
+	last     time.Time
+	p        Progress
+}
+
+// startProgress starts tracking the retrieval of a response.
+func (r *Repo) startProgress() *progress {
+	now := time.Now()
+	return &progress{
+		f:        r.progressFunc,
+		interval: r.progressInterval,
+		start:    now,
+		last:     now,
+	}
+}
+
+// track returns a reader which counts the bytes read from body.
+func (p *progress) track(body io.Reader) io.Reader {
+	p.r = body
+	return p
+}
+
+func (p *progress) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.p.Bytes += int64(n)
+	p.report()
+	return n, err
+}
+
+// row counts a decoded solution or triple.
+func (p *progress) row() error {
+	p.p.Rows++
+	p.report()
+	return nil
+}
+
+// done makes the final report, with the total number of rows decoded.
+func (p *progress) done(rows int) {
+	if p.f == nil {
+		return
+	}
+	p.p.Rows = rows
+	p.p.Elapsed = time.Since(p.start)
+	p.p.Done = true
+	p.f(p.p)
+}
+
+// report calls the progress function, if the interval has passed since the
+// last report.
+func (p *progress) report() {
+	if p.f == nil {
+		return
+	}
+	if now := time.Now(); now.Sub(p.last) >= p.interval {
+		p.last = now
+		p.p.Elapsed = now.Sub(p.start)
+		p.f(p.p)
+	}
+}
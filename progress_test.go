@@ -0,0 +1,44 @@
+package sparql
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReportProgress(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/sparql-results+json")
+		w.Write([]byte(testResults))
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL, "ontotext")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var reports []Progress
+	_, err = repo.Query("SELECT * WHERE { ?s ?p ?o }", ReportProgress(0, func(p Progress) {
+		reports = append(reports, p)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(reports) < 2 {
+		t.Fatalf("Got %d progress reports; want at least 2", len(reports))
+	}
+	last := reports[len(reports)-1]
+	if !last.Done || last.Rows != 2 || last.Bytes != int64(len(testResults)) {
+		t.Errorf("Got final report %+v; want Done with 2 rows and %d bytes", last, len(testResults))
+	}
+	for i, p := range reports[:len(reports)-1] {
+		if p.Done {
+			t.Errorf("Report %d is marked done before the last one", i)
+		}
+		if i > 0 && p.Bytes < reports[i-1].Bytes {
+			t.Errorf("Report %d has fewer bytes than the one before", i)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package sparql
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+const testEmptyResults = `{"head": {"vars": []}, "results": {"bindings": []}}`
+
+func TestQueryParam(t *testing.T) {
+	var got []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := req.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		got = req.Form["timeout"]
+		w.Write([]byte(testEmptyResults))
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL, "ontotext", QueryParam("timeout", "10"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = repo.Query("SELECT * WHERE { ?s ?p ?o }"); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0] != "10" {
+		t.Errorf("Got timeout params %v; want [10]", got)
+	}
+
+	if _, err = repo.Query("SELECT * WHERE { ?s ?p ?o }", QueryParam("timeout", "20")); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[1] != "20" {
+		t.Errorf("Got timeout params %v; want [10 20]", got)
+	}
+
+	if _, err = repo.Query("SELECT * WHERE { ?s ?p ?o }"); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Errorf("Per-query param leaked into Repo: got %v", got)
+	}
+}
+
+func TestClose(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(testEmptyResults))
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL, "ontotext")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = repo.Query("SELECT * WHERE { ?s ?p ?o }"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = repo.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = repo.Close(); err != nil {
+		t.Errorf("Second Close() returned %v; want nil", err)
+	}
+	if _, err = repo.Query("SELECT * WHERE { ?s ?p ?o }"); err != ErrClosed {
+		t.Errorf("Query() after Close() returned %v; want ErrClosed", err)
+	}
+}
+
+func TestWith(t *testing.T) {
+	var graph string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		graph = req.FormValue("default-graph-uri")
+		w.Write([]byte(testEmptyResults))
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL, "ontotext")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ro, err := repo.With(DefaultGraph("http://example.org/g"), ReadOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = ro.Query("SELECT * WHERE { ?s ?p ?o }"); err != nil {
+		t.Fatal(err)
+	}
+	if graph != "http://example.org/g" {
+		t.Errorf("Got default graph %q; want http://example.org/g", graph)
+	}
+	if _, err = ro.ConstructFormat("DELETE WHERE { ?s ?p ?o }", "text/turtle"); err != ErrReadOnly {
+		t.Errorf("Update on read-only Repo returned %v; want ErrReadOnly", err)
+	}
+
+	if _, err = repo.Query("SELECT * WHERE { ?s ?p ?o }"); err != nil {
+		t.Fatal(err)
+	}
+	if graph != "" {
+		t.Errorf("Derived Repo options leaked into original: got default graph %q", graph)
+	}
+
+	ro.Close()
+	if _, err = repo.Query("SELECT * WHERE { ?s ?p ?o }"); err != nil {
+		t.Errorf("Closing derived Repo closed original: %v", err)
+	}
+}
+
+func TestEndpointCredentials(t *testing.T) {
+	var user, pass string
+	var ok bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		user, pass, ok = req.BasicAuth()
+		w.Write([]byte(testEmptyResults))
+	}))
+	defer ts.Close()
+
+	u, _ := url.Parse(ts.URL)
+	u.User = url.UserPassword("dba", "s3cret")
+
+	repo, err := NewRepo(u.String(), "ontotext")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.endpoint != ts.URL {
+		t.Errorf("Got endpoint %q; want credentials stripped: %q", repo.endpoint, ts.URL)
+	}
+
+	if _, err = repo.Query("SELECT * WHERE { ?s ?p ?o }"); err != nil {
+		t.Fatal(err)
+	}
+	if !ok || user != "dba" || pass != "s3cret" {
+		t.Errorf("Got basic auth %q:%q (%v); want dba:s3cret", user, pass, ok)
+	}
+}
+
+func TestNewRepoInvalidEndpoint(t *testing.T) {
+	tests := []string{
+		"",
+		"localhost:8890/sparql",
+		"ftp://localhost/sparql",
+		"http:///sparql",
+		"http://localhost/sparql#frag",
+	}
+	for _, addr := range tests {
+		if _, err := NewRepo(addr, "ontotext"); err == nil {
+			t.Errorf("NewRepo(%q) succeeded; want error", addr)
+		}
+	}
+
+	repo, err := NewRepo(" HTTP://LocalHost:8890/sparql ", "ontotext")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.endpoint != "http://localhost:8890/sparql" {
+		t.Errorf("Got endpoint %q; want http://localhost:8890/sparql", repo.endpoint)
+	}
+}
+
+func TestProbeEndpoint(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	if _, err := NewRepo(ts.URL, "ontotext"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewRepo(ts.URL, "ontotext", ProbeEndpoint()); err == nil {
+		t.Error("NewRepo() with ProbeEndpoint() against failing endpoint succeeded; want error")
+	}
+}
+
+func TestRewriteQuery(t *testing.T) {
+	var got string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got = req.FormValue("query")
+		w.Write([]byte(testEmptyResults))
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL, "ontotext",
+		RewriteQuery(func(q string) (string, error) { return q + " LIMIT 10", nil }),
+		RewriteQuery(func(q string) (string, error) { return "# tenant: a\n" + q, nil }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = repo.Query("SELECT * WHERE { ?s ?p ?o }"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "# tenant: a\nSELECT * WHERE { ?s ?p ?o } LIMIT 10"; got != want {
+		t.Errorf("Got query %q; want %q", got, want)
+	}
+
+	errDenied := errors.New("denied")
+	_, err = repo.Query("SELECT * WHERE { ?s ?p ?o }",
+		RewriteQuery(func(q string) (string, error) { return "", errDenied }))
+	if err != errDenied {
+		t.Errorf("Query() with failing rewrite returned %v; want errDenied", err)
+	}
+}
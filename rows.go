@@ -0,0 +1,37 @@
+package sparql
+
+import "errors"
+
+// errTooManyRows stops decoding results with more solutions than allowed
+// by MaxRows.
+var errTooManyRows = errors.New("sparql: too many rows")
+
+// MaxRows limits the number of solutions returned by Query to n. Decoding
+// stops once the limit is exceeded, and the connection is closed rather than
+// reading the rest of the response, with the Truncated field of the results
+// set. This protects against queries which unexpectedly return huge numbers
+// of solutions; use LIMIT in the query to ask the store for fewer.
+//
+// Only JSON results are decoded incrementally; results in other formats are
+// read completely before being truncated.
+func MaxRows(n int) func(*Repo) error {
+	return func(r *Repo) error {
+		r.maxRows = n
+		return nil
+	}
+}
+
+// limitRows wraps the row callback of decodeJSON to stop decoding after
+// one solution more than MaxRows, which tells whether any are left out.
+func (r *Repo) limitRows(row func() error) func() error {
+	if r.maxRows <= 0 {
+		return row
+	}
+	n := 0
+	return func() error {
+		if n++; n > r.maxRows {
+			return errTooManyRows
+		}
+		return row()
+	}
+}
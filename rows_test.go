@@ -0,0 +1,68 @@
+package sparql
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMaxRows(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/sparql-results+json")
+		fmt.Fprint(w, `{"head": {"vars": ["n"]}, "results": {"bindings": [`)
+		for i := 0; i < 100000; i++ {
+			if i > 0 {
+				fmt.Fprint(w, ",")
+			}
+			if _, err := fmt.Fprintf(w, `{"n": {"type": "literal", "value": "%d"}}`, i); err != nil {
+				return
+			}
+		}
+		fmt.Fprint(w, `]}}`)
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL, "fuseki")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := repo.Query("SELECT ?n WHERE { ?s ?p ?n }", MaxRows(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Results.Bindings) != 10 || !res.Truncated {
+		t.Errorf("Got %d solutions, truncated %v; want 10, truncated", len(res.Results.Bindings), res.Truncated)
+	}
+	if res.Results.Bindings[9]["n"].Value != "9" {
+		t.Errorf("Got last solution %v", res.Results.Bindings[9])
+	}
+
+	res, err = repo.Query("SELECT ?n WHERE { ?s ?p ?n }", MaxRows(100000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Results.Bindings) != 100000 || res.Truncated {
+		t.Errorf("Got %d solutions, truncated %v; want 100000, not truncated", len(res.Results.Bindings), res.Truncated)
+	}
+}
+
+func TestMaxRowsCSV(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		fmt.Fprint(w, "n\r\n1\r\n2\r\n3\r\n")
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL, "fuseki", Accept("text/csv"), MaxRows(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := repo.Query("SELECT ?n WHERE { ?s ?p ?n }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Results.Bindings) != 2 || !res.Truncated {
+		t.Errorf("Got %d solutions, truncated %v; want 2, truncated", len(res.Results.Bindings), res.Truncated)
+	}
+}
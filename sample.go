@@ -0,0 +1,62 @@
+package sparql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/knakk/rdf"
+)
+
+// Sample returns a random sample of at most n solutions of the graph
+// pattern, e.g. for spot checks of data quality. By default the solutions
+// are sampled by random ordering; for the database type "blazegraph",
+// single triple patterns are sampled with its sampling service instead,
+// which avoids ordering all matches. Any options given apply to this query
+// only.
+func (r *Repo) Sample(pattern string, n int, options ...func(*Repo) error) ([]map[string]rdf.Term, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("sparql: invalid sample size %d", n)
+	}
+
+	sample := dialects[r.dbType].sample
+	if sample == nil {
+		sample = randomOrderSample
+	}
+	res, err := r.Query(sample(pattern, n), options...)
+	if err != nil {
+		return nil, err
+	}
+	return res.Solutions(), nil
+}
+
+func randomOrderSample(pattern string, n int) string {
+	return fmt.Sprintf("SELECT * WHERE { %s } ORDER BY RAND() LIMIT %d", pattern, n)
+}
+
+// blazegraphSample uses the bd:sample service of Blazegraph, which only
+// samples single triple patterns.
+func blazegraphSample(pattern string, n int) string {
+	toks, err := lex(pattern)
+	if err != nil {
+		return randomOrderSample(pattern, n)
+	}
+	if len(toks) == 4 && toks[3].is(".") {
+		toks = toks[:3]
+	}
+	if len(toks) != 3 {
+		return randomOrderSample(pattern, n)
+	}
+	for _, t := range toks {
+		if t.kind == tokPunct || t.kind == tokOp {
+			return randomOrderSample(pattern, n)
+		}
+	}
+	return fmt.Sprintf(`PREFIX bd: <http://www.bigdata.com/rdf#>
+SELECT * WHERE {
+  SERVICE bd:sample {
+    %s .
+    bd:serviceParam bd:sample.limit %d .
+    bd:serviceParam bd:sample.sampleType "RANDOM" .
+  }
+}`, strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(pattern), ".")), n)
+}
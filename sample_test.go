@@ -0,0 +1,18 @@
+package sparql
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBlazegraphSample(t *testing.T) {
+	if q := blazegraphSample("?s a <http://example.org/T>", 10); !strings.Contains(q, "SERVICE bd:sample") {
+		t.Errorf("Got query %q for single triple pattern; want bd:sample service", q)
+	}
+	if q := blazegraphSample("?s ?p ?o .", 10); !strings.Contains(q, "    ?s ?p ?o .\n") {
+		t.Errorf("Got query %q for terminated triple pattern; want it terminated once", q)
+	}
+	if q := blazegraphSample("?s a ?t . ?t ?p ?o", 10); q != randomOrderSample("?s a ?t . ?t ?p ?o", 10) {
+		t.Errorf("Got query %q for multiple triple patterns; want random ordering", q)
+	}
+}
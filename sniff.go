@@ -0,0 +1,101 @@
+package sparql
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"mime"
+	"strings"
+
+	"github.com/knakk/rdf"
+)
+
+// SniffContent makes Repo inspect the response payload when the endpoint
+// responds with a different content type than was asked for, and parse it
+// according to what it looks like instead, logging a warning. Several
+// endpoints ignore the Accept header and always respond in their default
+// format.
+func SniffContent() func(*Repo) error {
+	return func(r *Repo) error {
+		r.sniff = true
+		return nil
+	}
+}
+
+// parseResults parses a query response, sniffing its format if it differs
+// from what was asked for. If row is not nil it is called for every solution
+// decoded, as far as the format can be streamed.
+func (r *Repo) parseResults(body io.Reader, contentType string, row func() error) (*Results, error) {
+	if !r.sniff || accepts(r.accept, contentType) {
+		return decodeResults(body, contentType, row)
+	}
+
+	br := bufio.NewReader(body)
+	sniffed := sniffResults(br)
+	r.logf("sparql: endpoint responded with %q to a request accepting %q; parsing as %s",
+		contentType, r.accept, sniffed)
+	return decodeResults(br, sniffed, row)
+}
+
+// graphFormat returns the RDF serialization format of a construct response,
+// sniffing it from the payload if the content type is not a known format.
+func (r *Repo) graphFormat(contentType string, body []byte) rdf.Format {
+	mt, _, _ := mime.ParseMediaType(contentType)
+	switch mt {
+	case "text/turtle":
+		return rdf.Turtle
+	case "application/n-triples":
+		return rdf.NTriples
+	case "application/rdf+xml":
+		return rdf.RDFXML
+	}
+	if !r.sniff {
+		return rdf.Turtle
+	}
+
+	f, name := rdf.Turtle, "text/turtle"
+	if b := bytes.TrimSpace(body); len(b) > 0 && b[0] == '<' && !bytes.HasPrefix(b, []byte("<http")) {
+		// A leading IRI is Turtle, anything else starting with '<' is XML.
+		f, name = rdf.RDFXML, "application/rdf+xml"
+	}
+	r.logf("sparql: endpoint responded with %q to a construct query; parsing as %s", contentType, name)
+	return f
+}
+
+// sniffResults guesses the media type of a query response from its first bytes.
+func sniffResults(br *bufio.Reader) string {
+	peek, _ := br.Peek(512)
+	line := string(bytes.TrimLeft(peek, " \t\r\n\ufeff"))
+	switch {
+	case strings.HasPrefix(line, "{"):
+		return "application/sparql-results+json"
+	case strings.HasPrefix(line, "<"):
+		return "application/sparql-results+xml"
+	}
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	if strings.HasPrefix(line, "?") || strings.Contains(line, "\t") {
+		return "text/tab-separated-values"
+	}
+	return "text/csv"
+}
+
+// accepts reports whether the media type is among those listed in the
+// Accept header value.
+func accepts(accept, mediaType string) bool {
+	mt, _, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		return false
+	}
+	for _, a := range strings.Split(accept, ",") {
+		at, _, err := mime.ParseMediaType(a)
+		if err != nil {
+			continue
+		}
+		if at == mt || at == "*/*" || strings.HasSuffix(at, "/*") && strings.HasPrefix(mt, at[:len(at)-1]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package sparql
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSniffContent(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(testXMLResults))
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL, "ontotext")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = repo.Query("SELECT * WHERE { ?s ?p ?o }"); err == nil {
+		t.Error("Query() without SniffContent() parsed XML served as text/html; want error")
+	}
+
+	res, err := repo.Query("SELECT * WHERE { ?s ?p ?o }", SniffContent())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Results.Bindings) != 2 {
+		t.Errorf("Got %d solutions, want 2", len(res.Results.Bindings))
+	}
+}
+
+func TestAccepts(t *testing.T) {
+	tests := []struct {
+		accept, mediaType string
+		want              bool
+	}{
+		{"application/sparql-results+json", "application/sparql-results+json; charset=utf-8", true},
+		{"application/sparql-results+xml, application/sparql-results+json;q=0.9", "application/sparql-results+json", true},
+		{"application/sparql-results+json", "text/html", false},
+		{"text/*", "text/csv", true},
+		{"*/*", "text/csv", true},
+		{"application/sparql-results+json", "", false},
+	}
+	for _, test := range tests {
+		if got := accepts(test.accept, test.mediaType); got != test.want {
+			t.Errorf("accepts(%q, %q) = %v; want %v", test.accept, test.mediaType, got, test.want)
+		}
+	}
+}
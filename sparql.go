@@ -6,7 +6,9 @@ package sparql
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/knakk/rdf"
@@ -26,6 +28,13 @@ func init() {
 type Results struct {
 	Head    header
 	Results results
+
+	// Boolean holds the result of an ASK query.
+	Boolean bool
+
+	// Truncated reports whether solutions were left out, as there were more
+	// than allowed by the MaxRows option.
+	Truncated bool
 }
 
 type header struct {
@@ -49,12 +58,94 @@ type binding struct {
 // ParseJSON takes an application/sparql-results+json response and parses it
 // into a Results struct.
 func ParseJSON(r io.Reader) (*Results, error) {
+	return decodeJSON(r, nil)
+}
+
+// decodeJSON parses an application/sparql-results+json response, decoding
+// the solutions one at a time so that big result sets can be streamed. If
+// row is not nil it is called after each solution is decoded; decoding stops
+// if it returns an error.
+func decodeJSON(r io.Reader, row func() error) (*Results, error) {
 	var res Results
-	err := json.NewDecoder(r).Decode(&res)
+	dec := json.NewDecoder(r)
+
+	err := decodeObject(dec, func(key string) error {
+		switch strings.ToLower(key) {
+		case "head":
+			return dec.Decode(&res.Head)
+		case "boolean":
+			return dec.Decode(&res.Boolean)
+		case "results":
+			return decodeObject(dec, func(key string) error {
+				switch strings.ToLower(key) {
+				case "distinct":
+					return dec.Decode(&res.Results.Distinct)
+				case "ordered":
+					return dec.Decode(&res.Results.Ordered)
+				case "bindings":
+					return decodeArray(dec, func() error {
+						var b map[string]binding
+						if err := dec.Decode(&b); err != nil {
+							return err
+						}
+						res.Results.Bindings = append(res.Results.Bindings, b)
+						if row != nil {
+							return row()
+						}
+						return nil
+					})
+				default:
+					return dec.Decode(new(json.RawMessage))
+				}
+			})
+		default:
+			return dec.Decode(new(json.RawMessage))
+		}
+	})
 
 	return &res, err
 }
 
+// decodeObject decodes a JSON object (or null), calling member to decode the
+// value of each key.
+func decodeObject(dec *json.Decoder, member func(key string) error) error {
+	t, err := dec.Token()
+	if err != nil || t == nil {
+		return err
+	}
+	if t != json.Delim('{') {
+		return fmt.Errorf("sparql: unexpected %v in JSON results, want object", t)
+	}
+	for dec.More() {
+		if t, err = dec.Token(); err != nil {
+			return err
+		}
+		if err = member(t.(string)); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token()
+	return err
+}
+
+// decodeArray decodes a JSON array (or null), calling elem to decode each element.
+func decodeArray(dec *json.Decoder, elem func() error) error {
+	t, err := dec.Token()
+	if err != nil || t == nil {
+		return err
+	}
+	if t != json.Delim('[') {
+		return fmt.Errorf("sparql: unexpected %v in JSON results, want array", t)
+	}
+	for dec.More() {
+		if err = elem(); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token()
+	return err
+}
+
 // Bindings returns a map of the bound variables in the SPARQL response, where
 // each variable points to one or more RDF terms.
 func (r *Results) Bindings() map[string][]rdf.Term {
@@ -104,7 +195,11 @@ func termFromJSON(b binding) (rdf.Term, error) {
 		if b.Lang != "" {
 			return rdf.NewLangLiteral(b.Value, b.Lang)
 		}
-		return rdf.NewTypedLiteral(b.Value, xsdString), nil
+		if b.DataType == "" {
+			return rdf.NewTypedLiteral(b.Value, xsdString), nil
+		}
+		// SPARQL 1.1 results give the datatype with type literal.
+		fallthrough
 	case "typed-literal":
 		iri, err := rdf.NewIRI(b.DataType)
 		if err != nil {
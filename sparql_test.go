@@ -120,3 +120,15 @@ func TestSolutions(t *testing.T) {
 		}
 	}
 }
+
+func TestTermFromJSONLiteralDatatype(t *testing.T) {
+	// SPARQL 1.1 results give typed literals as type literal with a datatype.
+	got, err := termFromJSON(binding{Type: "literal", Value: "1.5", DataType: "http://www.w3.org/2001/XMLSchema#decimal"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	xsdDecimal, _ := rdf.NewIRI("http://www.w3.org/2001/XMLSchema#decimal")
+	if want := rdf.NewTypedLiteral("1.5", xsdDecimal); !rdf.TermsEqual(got, want) {
+		t.Errorf("Got \"%v\", want \"%v\"", got, want)
+	}
+}
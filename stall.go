@@ -0,0 +1,110 @@
+package sparql
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// ErrStalled is returned when no data has been received from the endpoint
+// for longer than allowed by the ReadIdleTimeout option.
+var ErrStalled = errors.New("sparql: response stalled")
+
+// ReadIdleTimeout makes requests fail with ErrStalled when waiting longer
+// than d for any data from the endpoint, whether for the response to start or
+// for more of it to arrive. Unlike Timeout, it does not bound the time taken
+// by a response which keeps making progress, so it suits very slow exports
+// and streams. Any data resets the deadline, so that endpoints sending
+// heartbeats, such as whitespace between JSON results, are not considered
+// stalled. Only the time spent waiting for data counts; time spent by the
+// application between reads does not.
+//
+// Stalled queries are re-established by repeating them, as many times as
+// allowed by the Retries option. Updates are never repeated.
+func ReadIdleTimeout(d time.Duration) func(*Repo) error {
+	return func(r *Repo) error {
+		r.readIdleTimeout = d
+		return nil
+	}
+}
+
+// watchdog aborts a request when it is idle for too long.
+type watchdog struct {
+	timer   *time.Timer
+	d       time.Duration
+	cancel  context.CancelFunc
+	stalled int32
+}
+
+// startWatchdog returns a context for a request, which is cancelled if the
+// request stalls, and the watchdog for it, or nil if there is no deadline.
+func (r *Repo) startWatchdog() (context.Context, *watchdog) {
+	if r.readIdleTimeout <= 0 {
+		return r.ctx, nil
+	}
+	ctx, cancel := context.WithCancel(r.ctx)
+	w := &watchdog{d: r.readIdleTimeout, cancel: cancel}
+	w.timer = time.AfterFunc(w.d, func() {
+		atomic.StoreInt32(&w.stalled, 1)
+		cancel()
+	})
+	return ctx, w
+}
+
+// wait arms the watchdog while waiting for data.
+func (w *watchdog) wait() { w.timer.Reset(w.d) }
+
+// idle disarms the watchdog, once data has arrived.
+func (w *watchdog) idle() { w.timer.Stop() }
+
+// err returns ErrStalled in place of err if the request stalled.
+func (w *watchdog) err(err error) error {
+	if err != nil && atomic.LoadInt32(&w.stalled) == 1 {
+		return ErrStalled
+	}
+	return err
+}
+
+func (w *watchdog) stop() {
+	w.timer.Stop()
+	w.cancel()
+}
+
+// watchedBody is a response body guarded by a watchdog.
+type watchedBody struct {
+	io.ReadCloser
+	w *watchdog
+}
+
+func (b *watchedBody) Read(p []byte) (int, error) {
+	b.w.wait()
+	n, err := b.ReadCloser.Read(p)
+	b.w.idle()
+	return n, b.w.err(err)
+}
+
+func (b *watchedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.w.stop()
+	return err
+}
+
+// reestablish calls f to run the query q, calling it again if it fails with
+// ErrStalled, up to the number of retries of Repo, unless q is an update.
+func (r *Repo) reestablish(q string, f func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := f()
+		if !errors.Is(err, ErrStalled) || attempt >= r.retries || isUpdate(q) {
+			return err
+		}
+		r.logf("sparql: response stalled, repeating query")
+
+		select {
+		case <-time.After(r.retryBackoff << uint(attempt)):
+		case <-r.ctx.Done():
+			return r.ctx.Err()
+		}
+	}
+}
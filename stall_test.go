@@ -0,0 +1,79 @@
+package sparql
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReadIdleTimeout(t *testing.T) {
+	var n int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		i := atomic.AddInt32(&n, 1)
+		w.Write([]byte(`{"head": {"vars": []}, `))
+		w.(http.Flusher).Flush()
+		switch {
+		case req.FormValue("heartbeat") != "":
+			// Heartbeats keep a slow response from being considered stalled.
+			for i := 0; i < 5; i++ {
+				time.Sleep(20 * time.Millisecond)
+				w.Write([]byte(" "))
+				w.(http.Flusher).Flush()
+			}
+		case i == 1:
+			// The first response stalls, until the client gives up.
+			<-req.Context().Done()
+			return
+		}
+		w.Write([]byte(`"results": {"bindings": []}}`))
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL, "fuseki", ReadIdleTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = repo.Query("SELECT * WHERE { ?s ?p ?o }"); err != ErrStalled {
+		t.Errorf("Got error %v; want ErrStalled", err)
+	}
+
+	// A stalled query is repeated if retries are allowed.
+	atomic.StoreInt32(&n, 0)
+	if _, err = repo.Query("SELECT * WHERE { ?s ?p ?o }", Retries(1, time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&n); n != 2 {
+		t.Errorf("Got %d requests; want 2", n)
+	}
+
+	if _, err = repo.Query("SELECT * WHERE { ?s ?p ?o }", QueryParam("heartbeat", "1")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadIdleTimeoutHeaders(t *testing.T) {
+	var n int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&n, 1)
+		select {
+		case <-time.After(time.Second):
+		case <-req.Context().Done():
+		}
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL, "fuseki", ReadIdleTimeout(20*time.Millisecond), Retries(2, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = repo.Query("SELECT * WHERE { ?s ?p ?o }"); !errors.Is(err, ErrStalled) {
+		t.Errorf("Got error %v; want ErrStalled", err)
+	}
+	// Stalls are retried as allowed, not by each layer.
+	if n := atomic.LoadInt32(&n); n != 3 {
+		t.Errorf("Got %d requests; want 3", n)
+	}
+}
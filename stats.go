@@ -0,0 +1,161 @@
+package sparql
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QueryStats holds the statistics of the queries of one shape, as identified
+// by their fingerprint.
+type QueryStats struct {
+	// Fingerprint is the normalized text of the queries: literals are
+	// replaced by ?, and whitespace and comments removed, so that queries
+	// only differing in their parameters share statistics. Queries beyond
+	// the 1000 most common fingerprints are counted under "(other)".
+	Fingerprint string
+
+	Count  int // number of queries
+	Errors int // number of queries which failed
+
+	Total time.Duration // total time taken by the queries
+
+	// Latency percentiles, over the last 1000 queries.
+	P50, P90, P99, Max time.Duration
+}
+
+// ErrorRate returns the fraction of the queries which failed.
+func (s QueryStats) ErrorRate() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Count)
+}
+
+const (
+	maxFingerprints = 1000
+	maxSamples      = 1000
+)
+
+// CollectStats makes Repo keep statistics of the queries and updates it
+// performs, per query shape, for Stats to return.
+func CollectStats() func(*Repo) error {
+	return func(r *Repo) error {
+		if err := r.repoScoped("CollectStats"); err != nil {
+			return err
+		}
+		r.stats = &stats{entries: make(map[string]*statsEntry)}
+		return nil
+	}
+}
+
+// Stats returns the statistics collected since CollectStats was given or
+// ResetStats called, the queries which took the most time in total first.
+// It returns nil if statistics are not collected.
+func (r *Repo) Stats() []QueryStats {
+	if r.stats == nil {
+		return nil
+	}
+	r.stats.mu.Lock()
+	defer r.stats.mu.Unlock()
+
+	all := make([]QueryStats, 0, len(r.stats.entries))
+	for fp, e := range r.stats.entries {
+		s := e.QueryStats
+		s.Fingerprint = fp
+		samples := append([]time.Duration(nil), e.samples...)
+		sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+		if n := len(samples); n > 0 {
+			s.P50 = samples[(n-1)*50/100]
+			s.P90 = samples[(n-1)*90/100]
+			s.P99 = samples[(n-1)*99/100]
+			s.Max = samples[n-1]
+		}
+		all = append(all, s)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Total != all[j].Total {
+			return all[i].Total > all[j].Total
+		}
+		return all[i].Fingerprint < all[j].Fingerprint
+	})
+	return all
+}
+
+// ResetStats discards the statistics collected so far.
+func (r *Repo) ResetStats() {
+	if r.stats == nil {
+		return
+	}
+	r.stats.mu.Lock()
+	r.stats.entries = make(map[string]*statsEntry)
+	r.stats.mu.Unlock()
+}
+
+// stats collects the statistics of a Repo.
+type stats struct {
+	mu      sync.Mutex
+	entries map[string]*statsEntry
+}
+
+type statsEntry struct {
+	QueryStats
+	samples []time.Duration // ring buffer of the latest latencies
+	next    int
+}
+
+// record adds a query to the statistics. It is safe to call on nil stats.
+func (s *stats) record(q string, d time.Duration, err error) {
+	if s == nil {
+		return
+	}
+	fp := Fingerprint(q)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[fp]
+	if !ok {
+		if len(s.entries) >= maxFingerprints {
+			fp = "(other)"
+			e = s.entries[fp]
+		}
+		if e == nil {
+			e = new(statsEntry)
+			s.entries[fp] = e
+		}
+	}
+	e.Count++
+	if err != nil {
+		e.Errors++
+	}
+	e.Total += d
+	if len(e.samples) < maxSamples {
+		e.samples = append(e.samples, d)
+	} else {
+		e.samples[e.next] = d
+		e.next = (e.next + 1) % maxSamples
+	}
+}
+
+// Fingerprint returns the normalized text of the query which CollectStats
+// groups statistics by; see QueryStats.
+func Fingerprint(q string) string {
+	toks, err := lex(q)
+	if err != nil {
+		return strings.Join(strings.Fields(q), " ")
+	}
+	parts := make([]string, 0, len(toks))
+	for _, t := range toks {
+		switch {
+		case t.kind == tokString || t.kind == tokNumber || t.is("true") || t.is("false"):
+			parts = append(parts, "?")
+		case t.kind == tokLangTag:
+		case t.kind == tokWord:
+			parts = append(parts, strings.ToUpper(t.text))
+		default:
+			parts = append(parts, t.text)
+		}
+	}
+	return strings.Join(parts, " ")
+}
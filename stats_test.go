@@ -0,0 +1,144 @@
+package sparql
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	a := Fingerprint(`select * where { ?s <p> "Alice"@en ; <age> 42 } # comment`)
+	b := Fingerprint("SELECT *\nWHERE {\n\t?s <p> 'Bob' ;\n\t<age> 7.5\n}")
+	if a != b {
+		t.Errorf("Got different fingerprints:\n%s\n%s", a, b)
+	}
+	if want := "SELECT * WHERE { ?s <p> ? ; <age> ? }"; a != want {
+		t.Errorf("Got fingerprint %q; want %q", a, want)
+	}
+	if Fingerprint("SELECT * WHERE { ?s <p> ?o }") == Fingerprint("SELECT * WHERE { ?s <q> ?o }") {
+		t.Error("Got same fingerprint for different predicates")
+	}
+}
+
+func TestStats(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.Contains(req.FormValue("query"), "fail") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(testEmptyResults))
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL, "fuseki", CollectStats())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.Stats() == nil {
+		t.Error("Got nil stats before any query")
+	}
+	for _, name := range []string{"a", "b", "c", "fail"} {
+		repo.Query(`SELECT * WHERE { ?s <name> "` + name + `" }`)
+	}
+	if _, err := repo.Update(`INSERT DATA { <a> <name> "a" }`); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := repo.Stats()
+	if len(stats) != 2 {
+		t.Fatalf("Got %d fingerprints; want 2: %+v", len(stats), stats)
+	}
+	var sel QueryStats
+	for _, s := range stats {
+		if strings.HasPrefix(s.Fingerprint, "SELECT") {
+			sel = s
+		}
+	}
+	if sel.Count != 4 || sel.Errors != 1 || sel.ErrorRate() != 0.25 {
+		t.Errorf("Got %+v; want 4 queries, 1 error", sel)
+	}
+	if sel.Max < sel.P50 || sel.Total < sel.Max {
+		t.Errorf("Got inconsistent latencies %+v", sel)
+	}
+
+	repo.ResetStats()
+	if len(repo.Stats()) != 0 {
+		t.Error("Got stats after reset")
+	}
+
+	plain, err := NewRepo(ts.URL, "fuseki")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain.Stats() != nil {
+		t.Error("Got stats without CollectStats")
+	}
+}
+
+func TestStatsEntryPoints(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.FormValue("query") != "" {
+			w.Header().Set("Content-Type", "text/turtle")
+		}
+	}))
+	defer ts.Close()
+
+	limit := RewriteQuery(func(q string) (string, error) { return q + " LIMIT 10", nil })
+	repo, err := NewRepo(ts.URL, "fuseki", CollectStats(), limit, MaxUpdateSize(40))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = repo.Construct("CONSTRUCT WHERE { ?s ?p ?o }"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = repo.Update("INSERT DATA { <a> <p> 1 . <b> <p> 2 . <c> <p> 3 }"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Queries are recorded once per call, as given.
+	if n := len(repo.Stats()); n != 2 {
+		t.Errorf("Got %d fingerprints; want 2", n)
+	}
+	for _, s := range repo.Stats() {
+		if s.Count != 1 || strings.Contains(s.Fingerprint, "LIMIT") {
+			t.Errorf("Got %+v; want one call of the query as given", s)
+		}
+	}
+
+	// Uploads, and guarded updates in transactions, are recorded too.
+	tx := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case strings.HasSuffix(req.URL.Path, "/transactions"):
+			w.Header().Set("Location", "/repo/transactions/tx")
+			w.WriteHeader(http.StatusCreated)
+		case req.URL.Query().Get("action") == "QUERY":
+			w.Header().Set("Content-Type", "application/sparql-results+json")
+			w.Write([]byte(`{"head": {}, "boolean": true}`))
+		}
+	}))
+	defer tx.Close()
+	repo, err = NewRepo(tx.URL+"/repo", "ontotext", CollectStats())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = repo.Upload("", strings.NewReader("<a> <p> 1 ."), "text/turtle"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = repo.UpdateIf("ASK { <a> <p> 1 }", "DELETE DATA { <a> <p> 1 }"); err != nil {
+		t.Fatal(err)
+	}
+	var fingerprints []string
+	for _, s := range repo.Stats() {
+		fingerprints = append(fingerprints, s.Fingerprint)
+	}
+	sort.Strings(fingerprints)
+	if got, want := strings.Join(fingerprints, ", "), "ASK { <a> <p> ? }, DELETE DATA { <a> <p> ? }, UPLOAD <text/turtle>"; got != want {
+		t.Errorf("Got fingerprints %s; want %s", got, want)
+	}
+
+	if _, err = repo.Query("ASK {}", CollectStats()); err == nil {
+		t.Error("Got no error for CollectStats given to a single query")
+	}
+}
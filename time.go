@@ -0,0 +1,72 @@
+package sparql
+
+import (
+	"strings"
+	"time"
+)
+
+// TimeFormat controls how time.Time values are formatted as literals. Stores
+// compare xsd:dateTime values with different timezone offsets correctly, but
+// not with values without one, and string functions like STR or REGEX see
+// the lexical form only, so the format should match how the data is stored.
+type TimeFormat struct {
+	// UTC converts times to UTC, written with the Z suffix, instead of
+	// keeping the offset of their location.
+	UTC bool
+
+	// Date formats times as xsd:date literals of the date only, without a
+	// timezone, instead of xsd:dateTime.
+	Date bool
+
+	// Precision is the number of digits of fractional seconds written, up
+	// to 9, or as many as needed (and none for whole seconds) if negative.
+	Precision int
+}
+
+// defaultTimeFormat is the format of time.Time values in FormatLiteral,
+// Literal and Bind, keeping the offset and as many fractional digits as
+// needed.
+var defaultTimeFormat = TimeFormat{Precision: -1}
+
+// TimeLiterals sets the format of time.Time values bound with the Bind
+// method of Repo, to match how times are stored in the repository. Use the
+// Literal method of a TimeFormat to format individual values differently,
+// e.g.
+//
+//	TimeFormat{UTC: true, Precision: 3}.Literal(t)   // "2020-01-02T03:04:05.000Z"^^xsd:dateTime
+func TimeLiterals(f TimeFormat) func(*Repo) error {
+	return func(r *Repo) error {
+		r.timeFormat = f
+		return nil
+	}
+}
+
+// Format returns the lexical form of t in the format.
+func (f TimeFormat) Format(t time.Time) string {
+	if f.UTC {
+		t = t.UTC()
+	}
+	if f.Date {
+		return t.Format("2006-01-02")
+	}
+	layout := "2006-01-02T15:04:05"
+	switch p := f.Precision; {
+	case p < 0:
+		layout += ".999999999"
+	case p > 0:
+		if p > 9 {
+			p = 9
+		}
+		layout += "." + strings.Repeat("0", p)
+	}
+	return t.Format(layout + "Z07:00")
+}
+
+// Literal returns t as a literal of the format, for use in expressions or
+// with Bind.
+func (f TimeFormat) Literal(t time.Time) Expr {
+	if f.Date {
+		return TypedLiteral(f.Format(t), xsdNS+"date")
+	}
+	return TypedLiteral(f.Format(t), xsdNS+"dateTime")
+}
@@ -0,0 +1,62 @@
+package sparql
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeFormat(t *testing.T) {
+	tm := time.Date(2020, 1, 2, 23, 4, 5, 120000000, time.FixedZone("CET", 3600))
+	tests := []struct {
+		f    TimeFormat
+		want string
+	}{
+		{defaultTimeFormat, "2020-01-02T23:04:05.12+01:00"},
+		{TimeFormat{}, "2020-01-02T23:04:05+01:00"},
+		{TimeFormat{UTC: true, Precision: 3}, "2020-01-02T22:04:05.120Z"},
+		{TimeFormat{Precision: 12}, "2020-01-02T23:04:05.120000000+01:00"},
+		{TimeFormat{Date: true}, "2020-01-02"},
+		{TimeFormat{Date: true, UTC: true}, "2020-01-02"},
+	}
+	for _, test := range tests {
+		if got := test.f.Format(tm); got != test.want {
+			t.Errorf("Got %s formatting with %+v; want %s", got, test.f, test.want)
+		}
+	}
+
+	late := time.Date(2020, 1, 2, 23, 30, 0, 0, time.FixedZone("", -3600))
+	if got, want := (TimeFormat{Date: true, UTC: true}).Literal(late).String(), `"2020-01-03"^^<http://www.w3.org/2001/XMLSchema#date>`; got != want {
+		t.Errorf("Got %s; want %s", got, want)
+	}
+	if got, want := Literal(late.UTC()).String(), `"2020-01-03T00:30:00Z"^^<http://www.w3.org/2001/XMLSchema#dateTime>`; got != want {
+		t.Errorf("Got %s; want %s", got, want)
+	}
+}
+
+func TestTimeLiterals(t *testing.T) {
+	repo, err := NewRepo("http://localhost/sparql", "fuseki", TimeLiterals(TimeFormat{UTC: true, Precision: 3}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm := time.Date(2020, 1, 2, 23, 4, 5, 0, time.FixedZone("CET", 3600))
+	q := "SELECT * WHERE { ?s <updated> ?t }"
+	got, err := repo.Bind(q, map[string]interface{}{"t": tm})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `SELECT * WHERE { ?s <updated> "2020-01-02T22:04:05.000Z"^^<http://www.w3.org/2001/XMLSchema#dateTime> }`; got != want {
+		t.Errorf("Got query:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Other Repos, and the Bind function, keep the default format.
+	other, err := NewRepo("http://localhost/sparql", "fuseki")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `SELECT * WHERE { ?s <updated> "2020-01-02T23:04:05+01:00"^^<http://www.w3.org/2001/XMLSchema#dateTime> }`
+	for _, bind := range []func(string, map[string]interface{}) (string, error){other.Bind, Bind} {
+		if got, err := bind(q, map[string]interface{}{"t": tm}); err != nil || got != want {
+			t.Errorf("Got %s, %v; want %s", got, err, want)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package sparql
+
+import (
+	"strconv"
+	"time"
+)
+
+// WithServerTimeout asks the store to stop working on queries after d, by
+// the means the store offers: the timeout parameter of the virtuoso, fuseki,
+// ontotext and stardog database types, or the X-BIGDATA-MAX-QUERY-MILLIS
+// header of blazegraph. Unlike Timeout, which only makes the client give up,
+// this frees the resources on the server. Stores round the timeout to their
+// precision: seconds for ontotext, milliseconds for the others.
+//
+// It fails with ErrNotSupported for other database types.
+func WithServerTimeout(d time.Duration) func(*Repo) error {
+	return func(r *Repo) error {
+		f := dialects[r.dbType].serverTimeout
+		if f == nil {
+			return ErrNotSupported
+		}
+		f(r, d)
+		return nil
+	}
+}
+
+// millis returns d in whole milliseconds, rounded up.
+func millis(d time.Duration) string {
+	return strconv.FormatInt(int64((d+time.Millisecond-1)/time.Millisecond), 10)
+}
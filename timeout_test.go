@@ -0,0 +1,55 @@
+package sparql
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWithServerTimeout(t *testing.T) {
+	var param, header, custom string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		param = req.FormValue("timeout")
+		header = req.Header.Get("X-BIGDATA-MAX-QUERY-MILLIS")
+		custom = req.Header.Get("X-Custom")
+		w.Write([]byte(testEmptyResults))
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		dbType, param, header string
+	}{
+		{"virtuoso", "1500", ""},
+		{"fuseki", "1.5", ""},
+		{"ontotext", "2", ""},
+		{"stardog", "1500", ""},
+		{"blazegraph", "", "1500"},
+	}
+	for _, test := range tests {
+		repo, err := NewRepo(ts.URL, test.dbType, Header("X-Custom", "yes"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repo.Query("SELECT * WHERE { ?s ?p ?o }", WithServerTimeout(1500*time.Millisecond)); err != nil {
+			t.Fatal(err)
+		}
+		if param != test.param || header != test.header || custom != "yes" {
+			t.Errorf("%s: got timeout parameter %q and header %q; want %q and %q", test.dbType, param, header, test.param, test.header)
+		}
+		if _, err := repo.Query("SELECT * WHERE { ?s ?p ?o }"); err != nil {
+			t.Fatal(err)
+		}
+		if param != "" || header != "" {
+			t.Errorf("%s: timeout leaked from per-query option", test.dbType)
+		}
+	}
+
+	repo, err := NewRepo(ts.URL, "oracle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Query("SELECT * WHERE { ?s ?p ?o }", WithServerTimeout(time.Second)); err != ErrNotSupported {
+		t.Errorf("Got error %v; want ErrNotSupported", err)
+	}
+}
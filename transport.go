@@ -0,0 +1,223 @@
+package sparql
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/knakk/digest"
+)
+
+// DigestAuth configures Repo to use digest authentication on HTTP requests.
+func DigestAuth(username, password string) func(*Repo) error {
+	return func(r *Repo) error {
+		r.basicAuth = nil
+		r.digestUsername, r.digestPassword = username, password
+		r.client.Transport = r.roundTripper()
+		return nil
+	}
+}
+
+// BasicAuth configures Repo to use basic authentication on HTTP requests.
+func BasicAuth(username, password string) func(*Repo) error {
+	return basicAuth(url.UserPassword(username, password))
+}
+
+func basicAuth(user *url.Userinfo) func(*Repo) error {
+	return func(r *Repo) error {
+		r.digestUsername, r.digestPassword = "", ""
+		r.basicAuth = user
+		r.client.Transport = r.roundTripper()
+		return nil
+	}
+}
+
+// Timeout instructs the underlying HTTP transport to timeout after given duration.
+func Timeout(t time.Duration) func(*Repo) error {
+	return func(r *Repo) error {
+		r.client.Timeout = t
+		return nil
+	}
+}
+
+// TLSConfig sets the TLS configuration used when connecting to the endpoint,
+// e.g. to trust a private certificate authority or present a client certificate.
+func TLSConfig(c *tls.Config) func(*Repo) error {
+	return func(r *Repo) error {
+		if err := r.repoScoped("TLSConfig"); err != nil {
+			return err
+		}
+		r.ownTransport().TLSClientConfig = c
+		return nil
+	}
+}
+
+// Retries instructs Repo to retry queries up to n times when the request
+// fails, or the endpoint responds with a status indicating a temporary
+// failure. The delay before each retry starts at backoff and doubles on every
+// attempt. Updates are not retried, as they are not safe to repeat, unless
+// made safe with IdempotencyKeys.
+func Retries(n int, backoff time.Duration) func(*Repo) error {
+	return func(r *Repo) error {
+		r.retries, r.retryBackoff = n, backoff
+		return nil
+	}
+}
+
+// ownTransport returns the HTTP transport of Repo, making a private copy
+// first if it is shared with the Repo it was derived from, so that it can be
+// modified without affecting other Repos.
+func (r *Repo) ownTransport() *http.Transport {
+	if r.sharedTransport {
+		r.transport = r.transport.Clone()
+		r.sharedTransport = false
+		r.client.Transport = r.roundTripper()
+	}
+	return r.transport
+}
+
+// roundTripper returns the http.RoundTripper to be used by the HTTP client,
+// wrapping the transport with authentication if configured.
+func (r *Repo) roundTripper() http.RoundTripper {
+	if r.digestUsername == "" && r.digestPassword == "" {
+		return r.transport
+	}
+	t := digest.NewTransport(r.digestUsername, r.digestPassword)
+	t.Transport = r.transport
+	return t
+}
+
+// do sends the request, retrying as configured if it is safe to repeat.
+func (r *Repo) do(req *http.Request, idempotent bool) (*http.Response, error) {
+	// The headers are added to a copy, as the request may be sent again.
+	req = req.Clone(r.ctx)
+	if r.host != "" {
+		req.Host = r.host
+	}
+	for k, vs := range r.headers {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	if user := r.user(req.URL); user != nil {
+		password, _ := user.Password()
+		req.SetBasicAuth(user.Username(), password)
+	}
+	if ae := r.acceptEncoding(); ae != "" {
+		// Setting the header turns off the transparent gzip of the transport.
+		req.Header.Set("Accept-Encoding", ae)
+	}
+
+	for attempt := 0; ; attempt++ {
+		release, err := r.acquire()
+		if err != nil {
+			return nil, err
+		}
+		ctx, w := r.startWatchdog()
+		resp, err := r.client.Do(req.WithContext(ctx))
+		if err != nil {
+			release()
+			if w != nil {
+				w.stop()
+				err = w.err(err)
+			}
+		} else {
+			if w != nil {
+				w.idle()
+				resp.Body = &watchedBody{ReadCloser: resp.Body, w: w}
+			}
+			resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+			if err = r.decodeBody(resp); err != nil {
+				resp.Body.Close()
+				return nil, err
+			}
+		}
+		if !idempotent || attempt >= r.retries || !temporary(resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-time.After(r.retryBackoff << uint(attempt)):
+		case <-r.ctx.Done():
+			return nil, r.ctx.Err()
+		}
+
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// user returns the user to authenticate the request to u as, if any.
+func (r *Repo) user(u *url.URL) *url.Userinfo {
+	if r.updateUser != nil {
+		s := u.String()
+		if rest := strings.TrimPrefix(s, r.updateEndpoint); rest != s && (rest == "" || rest[0] == '?' || rest[0] == '/') {
+			return r.updateUser
+		}
+	}
+	return r.basicAuth
+}
+
+// temporary reports whether a failed request is worth retrying. Stalled
+// requests are left to reestablish, which repeats the whole query.
+func temporary(resp *http.Response, err error) bool {
+	if err != nil {
+		return err != ErrStalled
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// call sends a form-encoded request outside of the regular query flow, such
+// as to the administrative APIs of stores, and returns the response body.
+// Responses with a status other than 2xx are returned as errors.
+func (r *Repo) call(method, addr string, form url.Values) ([]byte, error) {
+	var body io.Reader
+	if method == "GET" || method == "DELETE" {
+		if len(form) > 0 {
+			addr += "?" + form.Encode()
+		}
+	} else {
+		body = strings.NewReader(form.Encode())
+	}
+
+	req, err := http.NewRequest(method, addr, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	resp, err := r.control().do(req, method == "GET")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("sparql: %s %s failed: %s: %s", method, addr, resp.Status, strings.TrimSpace(string(b)))
+	}
+	return b, nil
+}
@@ -0,0 +1,54 @@
+package sparql
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetries(t *testing.T) {
+	var n int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		n++
+		if n < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(testEmptyResults))
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL, "ontotext", Retries(2, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = repo.Query("SELECT * WHERE { ?s ?p ?o }"); err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("Got %d attempts; want 3", n)
+	}
+}
+
+func TestTransportOptionsPerQuery(t *testing.T) {
+	repo, err := NewRepo("http://localhost/sparql", "fuseki")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, opt := range map[string]func(*Repo) error{
+		"TLSConfig":   TLSConfig(&tls.Config{}),
+		"Resolver":    Resolver(&net.Resolver{}),
+		"DialAddress": DialAddress("127.0.0.1"),
+		"ServerName":  ServerName("example.com"),
+	} {
+		if _, err := repo.Query("SELECT * WHERE { ?s ?p ?o }", opt); err == nil {
+			t.Errorf("Got no error for %s given to a single query", name)
+		}
+		if _, err := repo.With(opt); err != nil {
+			t.Errorf("Got error %v for %s given to With", err, name)
+		}
+	}
+}
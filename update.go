@@ -0,0 +1,353 @@
+package sparql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxUpdateSize makes Update split INSERT DATA and DELETE DATA updates of
+// more than n bytes into several smaller updates, sent one after the other,
+// for stores or gateways which limit the size of requests. Updates with blank
+// nodes are not split, as blank nodes are scoped to a single update.
+func MaxUpdateSize(n int) func(*Repo) error {
+	return func(r *Repo) error {
+		r.maxUpdateSize = n
+		return nil
+	}
+}
+
+// Transactional makes Update send the parts of a split update (see
+// MaxUpdateSize) in a single transaction, so that either all or none of them
+// are applied. This is supported for the ontotext (GraphDB, and other RDF4J
+// based stores) and stardog database types; other stores apply the parts
+// independently.
+func Transactional() func(*Repo) error {
+	return func(r *Repo) error {
+		r.transactional = true
+		return nil
+	}
+}
+
+// UpdateResult holds the statistics reported by the store for an update.
+type UpdateResult struct {
+	Inserted int // number of triples inserted
+	Deleted  int // number of triples deleted
+
+	// Counted reports whether the store reported the numbers of triples;
+	// where it didn't, they are zero. Counts are available for the virtuoso
+	// and, where the store includes them in its response, the neptune and
+	// stardog database types.
+	Counted bool
+}
+
+// add adds the counts of the response to the update to res.
+func (res *UpdateResult) add(r *Repo, body []byte) {
+	if parse := dialects[r.dbType].updateResult; parse != nil {
+		if inserted, deleted, ok := parse(body); ok {
+			res.Inserted += inserted
+			res.Deleted += deleted
+			res.Counted = true
+		}
+	}
+}
+
+// Update performs the SPARQL update on the Repo. Any options given apply to
+// this update only.
+func (r *Repo) Update(q string, options ...func(*Repo) error) (*UpdateResult, error) {
+	if r.closed() {
+		return nil, ErrClosed
+	}
+	r, err := r.derive(options...)
+	if err != nil {
+		return nil, err
+	}
+	defer r.asks.clear()
+
+	start := time.Now()
+	res, err := r.update(q)
+	r.stats.record(q, time.Since(start), err)
+	return res, err
+}
+
+// update implements Update, once options are applied.
+func (r *Repo) update(q string) (*UpdateResult, error) {
+	parts := []string{q}
+	if r.maxUpdateSize > 0 && len(q) > r.maxUpdateSize {
+		parts = splitUpdate(q, r.maxUpdateSize)
+	}
+	if begin := dialects[r.dbType].begin; len(parts) > 1 && r.transactional && begin != nil {
+		return r.updateInTransaction(begin, parts)
+	}
+
+	var res UpdateResult
+	for _, part := range parts {
+		p := r.startProgress()
+		body, _, err := r.construct(part, "*/*", true, p)
+		if err != nil {
+			return nil, err
+		}
+		p.done(0)
+		res.add(r, body)
+	}
+	return &res, nil
+}
+
+// updateInTransaction performs the updates in a transaction, rolling it back
+// if any of them fails.
+func (r *Repo) updateInTransaction(begin func(*Repo) (*transaction, error), updates []string) (*UpdateResult, error) {
+	if r.readOnly {
+		return nil, ErrReadOnly
+	}
+	for i, u := range updates {
+		var err error
+		if updates[i], err = r.prepare(u); err != nil {
+			return nil, err
+		}
+		if r.dryRun {
+			if err := r.validate(updates[i], true); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if r.dryRun {
+		return &UpdateResult{}, nil
+	}
+
+	tx, err := begin(r)
+	if err != nil {
+		return nil, err
+	}
+	var res UpdateResult
+	for _, u := range updates {
+		body, err := tx.update(u)
+		if err != nil {
+			if rerr := tx.rollback(); rerr != nil {
+				r.logf("sparql: rolling back transaction: %v", rerr)
+			}
+			return nil, err
+		}
+		res.add(r, body)
+	}
+	if err := tx.commit(); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// splitUpdate splits an INSERT DATA or DELETE DATA update into updates of
+// about max bytes each, keeping whole triples and GRAPH blocks. Other updates
+// are returned as they are.
+func splitUpdate(q string, max int) []string {
+	toks, err := lex(q)
+	if err != nil {
+		return []string{q}
+	}
+	i := skipPrologue(toks)
+	if i+2 >= len(toks) || !(toks[i].is("INSERT") || toks[i].is("DELETE")) || !toks[i+1].is("DATA") || !toks[i+2].is("{") {
+		return []string{q}
+	}
+	end := closing(toks, i+2)
+	if end != len(toks)-1 {
+		// More than one operation.
+		return []string{q}
+	}
+
+	type statement struct{ graph, text string }
+	var stmts []statement
+	graph := ""
+	start := i + 3
+	for j := start; j < end; j++ {
+		switch t := toks[j]; {
+		case t.kind == tokBlank || t.is("[") || t.is("("):
+			return []string{q}
+		case t.is("GRAPH") && graph == "":
+			if j+2 >= end || !toks[j+2].is("{") {
+				return []string{q}
+			}
+			graph = toks[j+1].text
+			j += 2
+			start = j + 1
+		case t.is("}") && graph != "" || t.is("."):
+			if j > start {
+				stmts = append(stmts, statement{graph, strings.TrimSpace(q[toks[start].pos:t.pos])})
+			}
+			if t.is("}") {
+				graph = ""
+			}
+			start = j + 1
+		}
+	}
+	if start < end {
+		stmts = append(stmts, statement{graph, strings.TrimSpace(q[toks[start].pos:toks[end].pos])})
+	}
+
+	head := q[:toks[i+2].pos]
+	var (
+		parts []string
+		b     strings.Builder
+		cur   string // the GRAPH block open in b
+	)
+	flush := func() {
+		if cur != "" {
+			b.WriteString(" }")
+		}
+		b.WriteString(" }")
+		parts = append(parts, b.String())
+		b.Reset()
+		cur = ""
+	}
+	for _, s := range stmts {
+		if b.Len() > 0 && b.Len()+len(s.text)+len(s.graph)+16 > max {
+			flush()
+		}
+		if b.Len() == 0 {
+			b.WriteString(head + "{")
+		}
+		if s.graph != cur {
+			if cur != "" {
+				b.WriteString(" }")
+			}
+			if s.graph != "" {
+				b.WriteString(" GRAPH " + s.graph + " {")
+			}
+			cur = s.graph
+		}
+		b.WriteString(" " + s.text + " .")
+	}
+	if b.Len() > 0 {
+		flush()
+	}
+	if len(parts) == 0 {
+		return []string{q}
+	}
+	return parts
+}
+
+// transaction is a store-side transaction.
+type transaction struct {
+	query    func(q string) (*Results, error)
+	update   func(q string) ([]byte, error)
+	commit   func() error
+	rollback func() error
+}
+
+// rdf4jBegin starts a transaction with the RDF4J transaction protocol.
+func rdf4jBegin(r *Repo) (*transaction, error) {
+	req, err := http.NewRequest("POST", r.endpoint+"/transactions", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.control().do(req, false)
+	if err != nil {
+		return nil, err
+	}
+	b, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("sparql: starting transaction failed: %s: %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+	loc, err := resp.Location()
+	if err != nil {
+		return nil, fmt.Errorf("sparql: starting transaction failed: %v", err)
+	}
+	tx := loc.String()
+	return &transaction{
+		query: rdf4jQuery(r, tx),
+		update: func(q string) ([]byte, error) {
+			return r.call("PUT", tx+"?action=UPDATE", url.Values{"update": {q}})
+		},
+		commit: func() error {
+			_, err := r.call("PUT", tx+"?action=COMMIT", nil)
+			return err
+		},
+		rollback: func() error {
+			_, err := r.call("DELETE", tx, nil)
+			return err
+		},
+	}, nil
+}
+
+// stardogBegin starts a Stardog transaction.
+func stardogBegin(r *Repo) (*transaction, error) {
+	db := strings.TrimSuffix(r.endpoint, "/query")
+	id, err := r.call("POST", db+"/transaction/begin", nil)
+	if err != nil {
+		return nil, err
+	}
+	tx := strings.TrimSpace(string(id))
+	return &transaction{
+		query: stardogQuery(r, db, tx),
+		update: func(q string) ([]byte, error) {
+			return r.call("POST", db+"/"+tx+"/update", url.Values{"query": {q}})
+		},
+		commit: func() error {
+			_, err := r.call("POST", db+"/transaction/commit/"+tx, nil)
+			return err
+		},
+		rollback: func() error {
+			_, err := r.call("POST", db+"/transaction/rollback/"+tx, nil)
+			return err
+		},
+	}, nil
+}
+
+// virtuosoCounts matches the messages Virtuoso responds to updates with, like
+// "Insert into <g>, 3 (or less) triples -- done" or "Modify <g>, delete 1
+// (or less) and insert 2 (or less) triples -- done".
+var virtuosoCounts = regexp.MustCompile(`(?i)\b(insert|delete)(?: into| from)?(?: <[^>]*>,)? (\d+) \(or less\)`)
+
+// virtuosoUpdateResult parses the counts from Virtuoso's update messages.
+func virtuosoUpdateResult(body []byte) (inserted, deleted int, ok bool) {
+	for _, m := range virtuosoCounts.FindAllSubmatch(body, -1) {
+		n, _ := strconv.Atoi(string(m[2]))
+		if bytes.HasPrefix(bytes.ToLower(m[1]), []byte("insert")) {
+			inserted += n
+		} else {
+			deleted += n
+		}
+		ok = true
+	}
+	return inserted, deleted, ok
+}
+
+// jsonUpdateResult parses counts from a JSON response to an update, summing
+// any numbers under keys naming inserted or deleted triples.
+func jsonUpdateResult(body []byte) (inserted, deleted int, ok bool) {
+	var v interface{}
+	if json.Unmarshal(body, &v) != nil {
+		return 0, 0, false
+	}
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case []interface{}:
+			for _, x := range v {
+				walk(x)
+			}
+		case map[string]interface{}:
+			for k, x := range v {
+				n, isNum := x.(float64)
+				switch k = strings.ToLower(k); {
+				case isNum && (k == "inserted" || k == "added" || k == "insertcount" || k == "addedcount"):
+					inserted += int(n)
+					ok = true
+				case isNum && (k == "deleted" || k == "removed" || k == "deletecount" || k == "removedcount"):
+					deleted += int(n)
+					ok = true
+				default:
+					walk(x)
+				}
+			}
+		}
+	}
+	walk(v)
+	return inserted, deleted, ok
+}
@@ -0,0 +1,164 @@
+package sparql
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSplitUpdate(t *testing.T) {
+	q := "PREFIX ex: <http://example.org/>\nINSERT DATA { ex:a ex:p 1 . ex:b ex:p 2 ; ex:q 3 . GRAPH ex:g { ex:c ex:p 4 . ex:d ex:p 5 } ex:e ex:p 6 }"
+	parts := splitUpdate(q, 70)
+	want := []string{
+		"PREFIX ex: <http://example.org/>\nINSERT DATA { ex:a ex:p 1 . }",
+		"PREFIX ex: <http://example.org/>\nINSERT DATA { ex:b ex:p 2 ; ex:q 3 . }",
+		"PREFIX ex: <http://example.org/>\nINSERT DATA { GRAPH ex:g { ex:c ex:p 4 . } }",
+		"PREFIX ex: <http://example.org/>\nINSERT DATA { GRAPH ex:g { ex:d ex:p 5 . } }",
+		"PREFIX ex: <http://example.org/>\nINSERT DATA { ex:e ex:p 6 . }",
+	}
+	if strings.Join(parts, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Got parts:\n%s\nwant:\n%s", strings.Join(parts, "\n"), strings.Join(want, "\n"))
+	}
+	if parts := splitUpdate(q, 1000); len(parts) != 1 {
+		t.Errorf("Got %d parts with a big limit; want 1", len(parts))
+	}
+
+	for _, q := range []string{
+		"INSERT DATA { _:b <p> 1 . <a> <p> 2 }",
+		"INSERT DATA { <a> <p> 1 } ; INSERT DATA { <a> <p> 2 }",
+		"DELETE WHERE { ?s <p> 1 . ?s <p> 2 }",
+	} {
+		if parts := splitUpdate(q, 10); len(parts) != 1 || parts[0] != q {
+			t.Errorf("Got parts %q for %q; want it unsplit", parts, q)
+		}
+	}
+}
+
+func TestUpdateTransactional(t *testing.T) {
+	var calls []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls = append(calls, req.Method+" "+req.URL.Path+" "+req.URL.RawQuery)
+		switch {
+		case req.Method == "POST" && strings.HasSuffix(req.URL.Path, "/transactions"):
+			w.Header().Set("Location", req.URL.Path+"/tx1")
+			w.WriteHeader(http.StatusCreated)
+		case req.Method == "PUT" && req.FormValue("action") == "UPDATE" && strings.Contains(req.FormValue("update"), "<c>"):
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL+"/repositories/test", "ontotext", MaxUpdateSize(30), Transactional())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Update("INSERT DATA { <a> <p> 1 . <b> <p> 2 }"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"POST /repositories/test/transactions ",
+		"PUT /repositories/test/transactions/tx1 action=UPDATE",
+		"PUT /repositories/test/transactions/tx1 action=UPDATE",
+		"PUT /repositories/test/transactions/tx1 action=COMMIT",
+	}
+	if strings.Join(calls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Got requests:\n%s\nwant:\n%s", strings.Join(calls, "\n"), strings.Join(want, "\n"))
+	}
+
+	calls = nil
+	if _, err := repo.Update("INSERT DATA { <a> <p> 1 . <c> <p> 2 }"); err == nil {
+		t.Error("Got no error for failed update")
+	}
+	if last := calls[len(calls)-1]; last != "DELETE /repositories/test/transactions/tx1 " {
+		t.Errorf("Got last request %q; want rollback", last)
+	}
+}
+
+func TestUpdateResult(t *testing.T) {
+	tests := []struct {
+		dbType, body      string
+		inserted, deleted int
+		counted           bool
+	}{
+		{"virtuoso", `{"head": {"vars": ["callret-0"]}, "results": {"bindings": [{"callret-0": {"type": "literal", "value": "Insert into <http://example.org/g1>, 3 (or less) triples -- done"}}]}}`, 3, 0, true},
+		{"virtuoso", "Modify <http://example.org/g>, delete 1 (or less) and insert 2 (or less) triples -- done", 2, 1, true},
+		{"virtuoso", "Delete from <http://example.org/g>, 4 (or less) triples -- done", 0, 4, true},
+		{"virtuoso", "", 0, 0, false},
+		{"stardog", `{"added": 5, "removed": 1}`, 5, 1, true},
+		{"neptune", `[{"type": "UpdateEvent", "inserted": 2}, {"type": "UpdateEvent", "deleted": 3}]`, 2, 3, true},
+		{"neptune", `{"type": "commit", "totalElapsedMillis": 5}`, 0, 0, false},
+		{"fuseki", `{"added": 5}`, 0, 0, false},
+	}
+	for _, test := range tests {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte(test.body))
+		}))
+		repo, err := NewRepo(ts.URL, test.dbType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := repo.Update("INSERT DATA { <a> <p> 1 }")
+		ts.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Inserted != test.inserted || res.Deleted != test.deleted || res.Counted != test.counted {
+			t.Errorf("%s: got %+v for %q; want %d inserted, %d deleted", test.dbType, *res, test.body, test.inserted, test.deleted)
+		}
+	}
+}
+
+func TestUpdateForms(t *testing.T) {
+	var got string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got = req.Method + " " + req.URL.Path + " " + req.FormValue("update")
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL+"/query", "fuseki", UpdateEndpoint(ts.URL+"/update"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range []string{
+		"CLEAR GRAPH <http://example.org/g>",
+		"LOAD <http://example.org/data.ttl>",
+		"DROP SILENT GRAPH <http://example.org/g>",
+		"COPY <http://example.org/a> TO <http://example.org/b>",
+	} {
+		if _, err = repo.Update(q); err != nil {
+			t.Fatal(err)
+		}
+		if want := "POST /update " + q; got != want {
+			t.Errorf("Got request %q; want %q", got, want)
+		}
+		if _, err = repo.Update(q, ReadOnly()); err != ErrReadOnly {
+			t.Errorf("Got error %v for %q through read-only repo; want ErrReadOnly", err, q)
+		}
+	}
+}
+
+func TestUpdateEndpointCredentials(t *testing.T) {
+	users := map[string]string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		users[req.URL.Path], _, _ = req.BasicAuth()
+		w.Write([]byte(testEmptyResults))
+	}))
+	defer ts.Close()
+
+	u := strings.Replace(ts.URL, "http://", "http://writer:secret@", 1)
+	repo, err := NewRepo(strings.Replace(ts.URL, "http://", "http://reader:secret@", 1)+"/query", "fuseki",
+		UpdateEndpoint(u+"/update"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = repo.Query("SELECT * WHERE { ?s ?p ?o }"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = repo.Update("INSERT DATA { <a> <p> 1 }"); err != nil {
+		t.Fatal(err)
+	}
+	if users["/query"] != "reader" || users["/update"] != "writer" {
+		t.Errorf("Got users %v; want reader for queries and writer for updates", users)
+	}
+}
@@ -0,0 +1,206 @@
+package sparql
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// queryForms are the keywords starting a query or an update operation.
+var queryForms = map[string]bool{
+	"SELECT": true, "ASK": true, "CONSTRUCT": true, "DESCRIBE": true,
+	"INSERT": true, "DELETE": true, "LOAD": true, "CLEAR": true, "CREATE": true,
+	"DROP": true, "COPY": true, "MOVE": true, "ADD": true, "WITH": true,
+}
+
+// Validate checks the query for syntax errors client-side, returning a
+// *SyntaxError for the first one found. It does not implement the full
+// SPARQL grammar, but catches common mistakes: unterminated literals and
+// IRIs, unbalanced brackets, undeclared prefixes and missing projections.
+func Validate(q string) error {
+	toks, err := lex(q)
+	if err != nil {
+		return err
+	}
+
+	if err = checkBrackets(toks, len(q)); err != nil {
+		return err
+	}
+
+	prefixes := declaredPrefixes(toks)
+	for _, t := range toks {
+		if t.kind != tokPName {
+			continue
+		}
+		if p := t.text[:strings.Index(t.text, ":")]; !prefixes[p] {
+			return &SyntaxError{t.pos, fmt.Sprintf("undeclared prefix %q", p+":")}
+		}
+	}
+
+	i := skipPrologue(toks)
+	if i == len(toks) {
+		return &SyntaxError{len(q), "missing query form, e.g. SELECT"}
+	}
+	if form := strings.ToUpper(toks[i].text); toks[i].kind != tokWord || !queryForms[form] {
+		return &SyntaxError{toks[i].pos, fmt.Sprintf("unexpected %q, want a query form such as SELECT", toks[i].text)}
+	}
+
+	if toks[i].is("SELECT") {
+		j := i + 1
+		if j < len(toks) && (toks[j].is("DISTINCT") || toks[j].is("REDUCED")) {
+			j++
+		}
+		if j == len(toks) || !(toks[j].is("*") || toks[j].is("(") || toks[j].kind == tokVar) {
+			return &SyntaxError{toks[i].pos, "SELECT without projection, want * or variables"}
+		}
+	}
+
+	return nil
+}
+
+// checkBrackets verifies that brackets are balanced and properly nested.
+func checkBrackets(toks []token, end int) error {
+	var open []token
+	for _, t := range toks {
+		if t.kind != tokPunct {
+			continue
+		}
+		switch t.text {
+		case "{", "(", "[":
+			open = append(open, t)
+		case "}", ")", "]":
+			want := map[string]string{"}": "{", ")": "(", "]": "["}[t.text]
+			if len(open) == 0 || open[len(open)-1].text != want {
+				return &SyntaxError{t.pos, fmt.Sprintf("unexpected %q", t.text)}
+			}
+			open = open[:len(open)-1]
+		}
+	}
+	if len(open) > 0 {
+		t := open[len(open)-1]
+		return &SyntaxError{t.pos, fmt.Sprintf("unclosed %q", t.text)}
+	}
+	return nil
+}
+
+// declaredPrefixes returns the prefixes declared in the prologue of the query.
+func declaredPrefixes(toks []token) map[string]bool {
+	prefixes := make(map[string]bool)
+	for i := 0; i+1 < len(toks); i++ {
+		if toks[i].is("PREFIX") && toks[i+1].kind == tokPName {
+			prefixes[strings.TrimSuffix(toks[i+1].text, ":")] = true
+		}
+	}
+	return prefixes
+}
+
+// skipPrologue returns the index of the first token after the BASE and
+// PREFIX declarations of the query.
+func skipPrologue(toks []token) int {
+	i := 0
+	for i < len(toks) {
+		switch {
+		case toks[i].is("BASE"):
+			i += 2
+		case toks[i].is("PREFIX"):
+			i += 3
+		default:
+			return i
+		}
+	}
+	return len(toks)
+}
+
+// DryRun makes Repo validate queries and updates instead of executing them.
+// Queries are checked client-side (see Validate), and by the store where it
+// offers validation, or can explain queries without executing them.
+// Successfully validated queries return empty results. This is useful for
+// checking a catalogue of queries against a live store from CI.
+func DryRun() func(*Repo) error {
+	return func(r *Repo) error {
+		r.dryRun = true
+		return nil
+	}
+}
+
+// Validate checks the query for errors without executing it: client-side
+// (see the Validate function), and by the store where it offers validation.
+// Any options given apply to this query only.
+func (r *Repo) Validate(q string, options ...func(*Repo) error) error {
+	if r.closed() {
+		return ErrClosed
+	}
+	r, err := r.derive(options...)
+	if err != nil {
+		return err
+	}
+	if q, err = r.rewrite(r.addPrefixes(q)); err != nil {
+		return err
+	}
+	return r.validate(q, isUpdate(q))
+}
+
+// validate checks the prepared query, or the update if update is true.
+func (r *Repo) validate(q string, update bool) error {
+	if err := Validate(q); err != nil {
+		return err
+	}
+	if v := dialects[r.dbType].validate; v != nil {
+		return v(r, q, update)
+	}
+	return nil
+}
+
+// fusekiValidate validates the query with the validation service of Fuseki.
+func fusekiValidate(r *Repo, q string, update bool) error {
+	u, err := url.Parse(r.endpoint)
+	if err != nil {
+		return err
+	}
+	kind := "query"
+	if update {
+		kind = "update"
+	}
+	u.Path, u.RawQuery = "/$/validate/"+kind, ""
+
+	b, err := r.call("POST", u.String(), url.Values{kind: {q}, "output": {"json"}})
+	if err != nil {
+		return err
+	}
+
+	var res struct {
+		Errors []struct {
+			ParseError     string `json:"parseError"`
+			ParseErrorLine int    `json:"parseErrorLine"`
+			ParseErrorCol  int    `json:"parseErrorCol"`
+		} `json:"errors"`
+	}
+	if err = json.Unmarshal(b, &res); err != nil {
+		return fmt.Errorf("sparql: invalid response from validation service: %v", err)
+	}
+	if len(res.Errors) > 0 {
+		e := res.Errors[0]
+		return &SyntaxError{offset(q, e.ParseErrorLine, e.ParseErrorCol), e.ParseError}
+	}
+	return nil
+}
+
+// offset converts a 1-based line and column in q to a byte offset.
+func offset(q string, line, col int) int {
+	i := 0
+	for l := 1; l < line; l++ {
+		j := strings.IndexByte(q[i:], '\n')
+		if j < 0 {
+			break
+		}
+		i += j + 1
+	}
+	if col > 0 {
+		i += col - 1
+	}
+	if i > len(q) {
+		i = len(q)
+	}
+	return i
+}
@@ -0,0 +1,83 @@
+package sparql
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	valid := []string{
+		"SELECT * WHERE { ?s ?p ?o }",
+		"PREFIX ex: <http://example.org/> SELECT ?s WHERE { ?s a ex:Thing ; ex:name \"x\"@en }",
+		"SELECT (COUNT(?s) AS ?n) WHERE { ?s ?p ?o FILTER(?o < 10 && ?o >= 2) }",
+		"ASK { <http://example.org/a> ?p \"\"\"multi\nline\"\"\" }",
+		"# comment with { brace\nCONSTRUCT { ?s ?p ?o } WHERE { ?s ?p ?o }",
+		"DESCRIBE <http://example.org/a>",
+		"INSERT DATA { <http://example.org/a> <http://example.org/b> 1.5e3 }",
+	}
+	for _, q := range valid {
+		if err := Validate(q); err != nil {
+			t.Errorf("Validate(%q) = %v; want nil", q, err)
+		}
+	}
+
+	invalid := []struct {
+		q      string
+		offset int
+	}{
+		{"SELECT * WHERE { ?s ?p ?o ", 15},
+		{"SELECT * WHERE { ?s ?p ?o ) }", 26},
+		{"SELECT * WHERE { ?s ?p \"unterminated }", 23},
+		{"SELECT * WHERE { ?s a ex:Thing }", 22},
+		{"SELECT WHERE { ?s ?p ?o }", 0},
+		{"PREFIX ex: <http://example.org/>", 32},
+		{"FETCH * WHERE { ?s ?p ?o }", 0},
+	}
+	for _, test := range invalid {
+		err := Validate(test.q)
+		se, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("Validate(%q) = %v; want *SyntaxError", test.q, err)
+			continue
+		}
+		if se.Offset != test.offset {
+			t.Errorf("Validate(%q) reported offset %d (%s); want %d", test.q, se.Offset, se.Msg, test.offset)
+		}
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	var queries, validations int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/$/validate/query" {
+			validations++
+			w.Write([]byte(`{"input": "", "errors": [{"parseError": "Encountered \"}\"", "parseErrorLine": 2, "parseErrorCol": 3}]}`))
+			return
+		}
+		queries++
+		w.Write([]byte(testEmptyResults))
+	}))
+	defer ts.Close()
+
+	repo, err := NewRepo(ts.URL+"/ds/sparql", "fuseki", DryRun())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = repo.Query("SELECT * WHERE { ?s ?p ?o "); err == nil {
+		t.Error("Query() of invalid query in dry-run mode succeeded; want error")
+	}
+	if validations != 0 {
+		t.Errorf("Query rejected client-side was sent for validation")
+	}
+
+	_, err = repo.Query("SELECT *\nWHERE { ?s ?p ?o }")
+	se, ok := err.(*SyntaxError)
+	if !ok || se.Offset != 11 {
+		t.Errorf("Query() in dry-run mode returned %v; want *SyntaxError from server at offset 11", err)
+	}
+	if queries != 0 {
+		t.Errorf("Got %d queries executed in dry-run mode; want 0", queries)
+	}
+}